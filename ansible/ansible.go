@@ -0,0 +1,199 @@
+// Package ansible generates Ansible dynamic inventories from IDB machines.
+//
+// Hosts are grouped by os, os_release, device_type, owner, severity_class, ucs_role and backup_brand,
+// e.g. "os_Debian" or "device_type_virtual". Characters not allowed in Ansible group names are replaced by
+// underscores. Unset values don't create groups. Generate fails with ErrGroupCollision if different
+// values map to the same group, e.g. os "release 8" and os_release "8". Soft-deleted machines, i.e.
+// with deleted_at set, are not part of the inventory.
+//
+// Host variables are the JSON fields of the machine prefixed with "idb_", e.g. "idb_cores", plus
+// idb_ipv4_addresses and idb_ipv6_addresses containing the addresses of all interfaces.
+package ansible
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+const varPrefix = "idb_"
+
+var invalidGroupChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// ErrGroupCollision is returned by Generate if two different grouping values have the same group name.
+type ErrGroupCollision struct {
+	Group string
+
+	// Grouping keys and values, e.g. "os" and "release 8".
+	First, Second [2]string
+}
+
+func (e *ErrGroupCollision) Error() string {
+	return fmt.Sprintf("group %v is used for %v %q and %v %q", e.Group, e.First[0], e.First[1], e.Second[0], e.Second[1])
+}
+
+// Group is an Ansible host group.
+type Group struct {
+	Hosts    []string `json:"hosts,omitempty"`
+	Children []string `json:"children,omitempty"`
+}
+
+// Inventory is an Ansible dynamic inventory.
+type Inventory struct {
+	Groups   map[string]*Group
+	HostVars map[string]map[string]interface{}
+}
+
+// groupName returns the group name for a grouping key and value, or "" if value is unset.
+func groupName(key, value string) string {
+	if value == "" {
+		return ""
+	}
+	return invalidGroupChars.ReplaceAllString(key+"_"+value, "_")
+}
+
+// group is a group membership of a machine.
+type group struct {
+	name string
+
+	// grouping key and value
+	origin [2]string
+}
+
+// groups returns the groups m belongs to.
+func groups(m *machine.Machine) []group {
+	var owner, deviceType, backupBrand string
+
+	if m.OwnerID != 0 {
+		owner = strconv.Itoa(m.OwnerID)
+	}
+
+	if m.DeviceTypeID != machine.DeviceTypeNone {
+		deviceType = m.DeviceTypeID.Name()
+	}
+
+	if m.BackupBrand != machine.BackupBrandNone {
+		backupBrand = strings.ToLower(strings.TrimPrefix(m.BackupBrand.String(), "BackupBrand"))
+	}
+
+	var memberships []group
+	for _, g := range [][2]string{
+		{"os", m.Os},
+		{"os_release", m.OsRelease},
+		{"device_type", deviceType},
+		{"owner", owner},
+		{"severity_class", m.SeverityClass},
+		{"ucs_role", m.UcsRole},
+		{"backup_brand", backupBrand},
+	} {
+		if name := groupName(g[0], g[1]); name != "" {
+			memberships = append(memberships, group{name, g})
+		}
+	}
+
+	return memberships
+}
+
+// HostVars returns the host variables of m.
+func HostVars(m *machine.Machine) (map[string]interface{}, error) {
+	fields, err := machine.Fields(m)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]interface{}, len(fields)+2)
+	for k, v := range fields {
+		vars[varPrefix+k] = v
+	}
+
+	ipv4 := []string{}
+	ipv6 := []string{}
+	for _, n := range m.Nics {
+		if n.IPAddress.Addr != "" {
+			ipv4 = append(ipv4, n.IPAddress.Addr)
+		}
+		if n.IPAddress.AddrV6 != "" {
+			ipv6 = append(ipv6, n.IPAddress.AddrV6)
+		}
+	}
+	vars[varPrefix+"ipv4_addresses"] = ipv4
+	vars[varPrefix+"ipv6_addresses"] = ipv6
+
+	return vars, nil
+}
+
+// Generate creates an inventory from machines, skipping deleted ones. All hosts are members of the
+// group "all", which has all other groups as children.
+func Generate(machines []machine.Machine) (*Inventory, error) {
+	inv := &Inventory{
+		Groups:   map[string]*Group{"all": new(Group)},
+		HostVars: make(map[string]map[string]interface{}),
+	}
+
+	// grouping key and value of every group, to detect collisions
+	origins := make(map[string][2]string)
+
+	for i := range machines {
+		m := &machines[i]
+		if !m.DeletedAt.IsZero() {
+			continue
+		}
+
+		vars, err := HostVars(m)
+		if err != nil {
+			return nil, err
+		}
+		inv.HostVars[m.Fqdn] = vars
+
+		inv.Groups["all"].Hosts = append(inv.Groups["all"].Hosts, m.Fqdn)
+
+		for _, membership := range groups(m) {
+			name := membership.name
+			if origin, ok := origins[name]; ok && origin != membership.origin {
+				return nil, &ErrGroupCollision{name, origin, membership.origin}
+			}
+			origins[name] = membership.origin
+
+			g, ok := inv.Groups[name]
+			if !ok {
+				g = new(Group)
+				inv.Groups[name] = g
+				inv.Groups["all"].Children = append(inv.Groups["all"].Children, name)
+			}
+			g.Hosts = append(g.Hosts, m.Fqdn)
+		}
+	}
+
+	for _, g := range inv.Groups {
+		sort.Strings(g.Hosts)
+		sort.Strings(g.Children)
+	}
+
+	return inv, nil
+}
+
+// List returns the inventory in the format expected from "--list", including the host variables in _meta.
+func (inv *Inventory) List() ([]byte, error) {
+	out := make(map[string]interface{}, len(inv.Groups)+1)
+	for name, g := range inv.Groups {
+		out[name] = g
+	}
+	out["_meta"] = map[string]interface{}{"hostvars": inv.HostVars}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// Host returns the variables of host in the format expected from "--host". Unknown hosts have no variables.
+func (inv *Inventory) Host(host string) ([]byte, error) {
+	vars, ok := inv.HostVars[host]
+	if !ok {
+		vars = map[string]interface{}{}
+	}
+
+	return json.MarshalIndent(vars, "", "  ")
+}
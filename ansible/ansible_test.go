@@ -0,0 +1,102 @@
+package ansible
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+var testMachines = []machine.Machine{
+	{Fqdn: "b.example.org", Os: "Debian", OsRelease: "8.6", DeviceTypeID: machine.DeviceTypeVirtual, OwnerID: 3, BackupBrand: machine.BackupBrandBacula,
+		Nics: []machine.Nic{{Name: "eth0", IPAddress: machine.IPAddress{Addr: "10.0.0.2", AddrV6: "2001:db8::2"}}}},
+	{Fqdn: "a.example.org", Os: "Debian", UcsRole: "domain controller"},
+}
+
+func TestGenerate(t *testing.T) {
+	inv, err := Generate(testMachines)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{
+		"all":                        {"a.example.org", "b.example.org"},
+		"os_Debian":                  {"a.example.org", "b.example.org"},
+		"os_release_8_6":             {"b.example.org"},
+		"device_type_virtual":        {"b.example.org"},
+		"owner_3":                    {"b.example.org"},
+		"backup_brand_bacula":        {"b.example.org"},
+		"ucs_role_domain_controller": {"a.example.org"},
+	}
+
+	if len(inv.Groups) != len(expected) {
+		t.Errorf("Got %v groups, expected %v", len(inv.Groups), len(expected))
+	}
+	for name, hosts := range expected {
+		g, ok := inv.Groups[name]
+		if !ok || !reflect.DeepEqual(g.Hosts, hosts) {
+			t.Errorf("Group %v: got %+v, expected %v", name, g, hosts)
+		}
+	}
+	if len(inv.Groups["all"].Children) != len(expected)-1 {
+		t.Errorf("Unexpected children of all: %v", inv.Groups["all"].Children)
+	}
+
+	buf, err := inv.Host("b.example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vars map[string]interface{}
+	err = json.Unmarshal(buf, &vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if vars["idb_os_release"] != "8.6" || vars["idb_owner_id"] != 3.0 || !reflect.DeepEqual(vars["idb_ipv6_addresses"], []interface{}{"2001:db8::2"}) {
+		t.Errorf("Unexpected host vars %v", vars)
+	}
+}
+
+func TestDeleted(t *testing.T) {
+	deleted := machine.Machine{Fqdn: "c.example.org", Os: "Ubuntu", DeletedAt: time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC)}
+
+	inv, err := Generate(append([]machine.Machine{deleted}, testMachines...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := inv.HostVars[deleted.Fqdn]; ok {
+		t.Error("Deleted machine has host vars")
+	}
+	if _, ok := inv.Groups["os_Ubuntu"]; ok || len(inv.Groups["all"].Hosts) != len(testMachines) {
+		t.Errorf("Deleted machine in groups: %+v", inv.Groups["all"])
+	}
+
+	buf, err := inv.Host(deleted.Fqdn)
+	if err != nil || string(buf) != "{}" {
+		t.Errorf("Unexpected vars of deleted host: %s %v", buf, err)
+	}
+}
+
+var collisionTests = [][]machine.Machine{
+	{{Fqdn: "a", Os: "release_8.6"}, {Fqdn: "b", OsRelease: "8.6"}},
+	{{Fqdn: "a", OsRelease: "8.6"}, {Fqdn: "b", OsRelease: "8_6"}},
+}
+
+func TestGroupCollision(t *testing.T) {
+	for _, machines := range collisionTests {
+		_, err := Generate(machines)
+		if _, ok := err.(*ErrGroupCollision); !ok {
+			t.Errorf("%+v: expected collision, got %v", machines, err)
+		}
+	}
+
+	// the same value of different machines is no collision
+	_, err := Generate([]machine.Machine{{Fqdn: "a", OsRelease: "8.6"}, {Fqdn: "b", OsRelease: "8.6"}})
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,233 @@
+package idbclient
+
+import (
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+
+	"github.com/idb-project/idbclient/attachment"
+)
+
+// ChecksumHeader is the response header of attachment downloads containing the checksum of the content.
+const ChecksumHeader = "X-Checksum"
+
+// ErrChecksum is returned if the checksum of transferred attachment content doesn't match.
+type ErrChecksum struct {
+	fqdn     string
+	id       int
+	expected string
+	actual   string
+}
+
+func (e *ErrChecksum) Error() string {
+	return fmt.Sprintf("checksum mismatch of attachment %v of %v: expected %v, got %v", e.id, e.fqdn, e.expected, e.actual)
+}
+
+// ErrAttachmentID is returned if an attachment ID is not positive.
+type ErrAttachmentID struct {
+	id int
+}
+
+func (e *ErrAttachmentID) Error() string {
+	return fmt.Sprintf("invalid attachment ID %v", e.id)
+}
+
+// attachmentURL returns the URL of the attachments of fqdn. The id is only added if it is positive.
+func (i *Idb) attachmentURL(fqdn string, id int) *url.URL {
+	u := i.joinBaseURL("machines", "attachments")
+
+	query := url.Values{}
+	query.Add("fqdn", fqdn)
+	if id > 0 {
+		query.Add("id", strconv.Itoa(id))
+	}
+	u.RawQuery = query.Encode()
+
+	return u
+}
+
+// ListAttachments retrieves the metadata of all attachments of the machine fqdn.
+func (i *Idb) ListAttachments(fqdn string) ([]attachment.Attachment, error) {
+	var attachments []attachment.Attachment
+	err := i.getJSON(i.attachmentURL(fqdn, 0), &attachments)
+	return attachments, err
+}
+
+// UploadAttachment uploads the content read from r as attachment name of the machine fqdn.
+// The content is streamed, not buffered in memory. Its checksum is sent after the content and
+// compared with the checksum of the stored attachment, which is returned.
+func (i *Idb) UploadAttachment(fqdn, name, contentType string, r io.Reader) (*attachment.Attachment, error) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	checksum := make(chan string, 1)
+
+	go func() {
+		sum, err := writeAttachment(mw, name, contentType, r)
+		checksum <- sum
+		pw.CloseWithError(err)
+	}()
+
+	// wait returns the checksum of the written content. Closing the pipe stops the writer if the
+	// request ended early. Waiting for it ensures r isn't read anymore after returning.
+	var sum string
+	finished := false
+	wait := func() string {
+		if !finished {
+			pr.Close()
+			sum = <-checksum
+			finished = true
+		}
+		return sum
+	}
+	defer wait()
+
+	request, err := http.NewRequest("POST", i.attachmentURL(fqdn, 0).String(), pr)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", mw.FormDataContentType())
+
+	response, err := i.request(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, newErrStatus(response.StatusCode, http.StatusOK, nil)
+	}
+
+	var a attachment.Attachment
+	err = i.decodeResponse(&a, response)
+	if err != nil {
+		return nil, err
+	}
+
+	// the writer fails if the server responded without reading the complete body
+	if wait() != a.Checksum {
+		return nil, &ErrChecksum{fqdn, a.ID, sum, a.Checksum}
+	}
+
+	return &a, nil
+}
+
+// writeAttachment writes the multipart body of an upload: the part "file" with the content and the
+// field "checksum". The checksum is returned.
+func writeAttachment(mw *multipart.Writer, name, contentType string, r io.Reader) (string, error) {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": "file", "filename": name}))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header.Set("Content-Type", contentType)
+
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return "", err
+	}
+
+	h := attachment.NewHash()
+	_, err = io.Copy(io.MultiWriter(part, h), r)
+	if err != nil {
+		return "", err
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	err = mw.WriteField("checksum", sum)
+	if err != nil {
+		return "", err
+	}
+
+	return sum, mw.Close()
+}
+
+// DownloadAttachment retrieves the content of the attachment id of the machine fqdn. The content is
+// streamed and must be closed by the caller. Reading returns ErrChecksum instead of io.EOF if the
+// content doesn't match the checksum sent by the IDB.
+func (i *Idb) DownloadAttachment(fqdn string, id int) (io.ReadCloser, error) {
+	if id <= 0 {
+		return nil, &ErrAttachmentID{id}
+	}
+
+	request, err := http.NewRequest("GET", i.attachmentURL(fqdn, id).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := i.request(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, newErrStatus(response.StatusCode, http.StatusOK, nil)
+	}
+
+	expected := response.Header.Get(ChecksumHeader)
+	if expected == "" {
+		return response.Body, nil
+	}
+
+	return &checksumReader{
+		ReadCloser: response.Body,
+		fqdn:       fqdn,
+		id:         id,
+		hash:       attachment.NewHash(),
+		expected:   expected,
+	}, nil
+}
+
+// checksumReader verifies the checksum of the content at EOF.
+type checksumReader struct {
+	io.ReadCloser
+	fqdn     string
+	id       int
+	hash     hash.Hash
+	expected string
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+
+	if err == io.EOF {
+		actual := hex.EncodeToString(r.hash.Sum(nil))
+		if actual != r.expected {
+			return n, &ErrChecksum{r.fqdn, r.id, r.expected, actual}
+		}
+	}
+	return n, err
+}
+
+// DeleteAttachment deletes the attachment id of the machine fqdn.
+func (i *Idb) DeleteAttachment(fqdn string, id int) error {
+	if id <= 0 {
+		return &ErrAttachmentID{id}
+	}
+
+	request, err := http.NewRequest("DELETE", i.attachmentURL(fqdn, id).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := i.request(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return newErrStatus(response.StatusCode, http.StatusOK, nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+// Package attachment contains the IDB attachment model. Attachments are files stored with a machine,
+// like config dumps or invoices.
+package attachment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+)
+
+// Attachment represents the metadata of a IDB attachment. The content is transferred separately.
+type Attachment struct {
+	// ID, unique per IDB.
+	ID int `json:"id"`
+
+	// File name, e.g. "invoice-2017.pdf".
+	Name string `json:"name"`
+
+	// MIME type of the content, e.g. "application/pdf".
+	ContentType string `json:"content_type,omitempty"`
+
+	// Size of the content in bytes.
+	Size int64 `json:"size"`
+
+	// Hex encoded SHA-256 of the content.
+	Checksum string `json:"checksum"`
+
+	Description string `json:"description,omitempty"`
+}
+
+// NewHash returns the hash used for checksums.
+func NewHash() hash.Hash {
+	return sha256.New()
+}
+
+// Checksum returns the checksum of data.
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
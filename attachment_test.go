@@ -0,0 +1,195 @@
+package idbclient_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/idb-project/idbclient"
+	"github.com/idb-project/idbclient/idbtest"
+	"github.com/idb-project/idbclient/machine"
+)
+
+// streamReader returns its first chunk immediately, then blocks until the server received the
+// request, which only happens before the end of the content if the upload is streamed.
+type streamReader struct {
+	s        *idbtest.Server
+	requests int
+	n        int
+}
+
+func (r *streamReader) Read(p []byte) (int, error) {
+	r.n++
+	if r.n == 2 {
+		deadline := time.Now().Add(5 * time.Second)
+		for r.s.Requests() == r.requests {
+			if time.Now().After(deadline) {
+				return 0, errors.New("upload not streamed")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if r.n > 3 {
+		return 0, io.EOF
+	}
+	return copy(p, "chunk"), nil
+}
+
+// endlessReader counts its reads and never ends.
+type endlessReader struct {
+	reads atomic.Int64
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	r.reads.Add(1)
+	time.Sleep(time.Millisecond)
+	return len(p), nil
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestAttachments(t *testing.T) {
+	s := idbtest.NewServer("secret")
+	defer s.Close()
+	s.Put(machine.Machine{Fqdn: "a.example.com"})
+	idb := s.Idb()
+
+	content := make([]byte, 4<<20)
+	rand.New(rand.NewSource(1)).Read(content)
+
+	a, err := idb.UploadAttachment("a.example.com", "dump.bin", "", bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Name != "dump.bin" || a.Size != int64(len(content)) || a.ContentType != "application/octet-stream" {
+		t.Log("uploaded:", a)
+		t.Fail()
+	}
+	if !bytes.Equal(s.Attachment("a.example.com", a.ID), content) {
+		t.Log("stored content differs")
+		t.Fail()
+	}
+
+	_, err = idb.UploadAttachment("unknown.example.com", "dump.bin", "", bytes.NewReader(content))
+	if status(err) != http.StatusNotFound {
+		t.Log("upload for unknown machine:", err)
+		t.Fail()
+	}
+
+	_, err = idb.UploadAttachment("a.example.com", "broken.txt", "text/plain", failingReader{})
+	if err == nil {
+		t.Log("upload of failing reader succeeded")
+		t.Fail()
+	}
+
+	_, err = idb.UploadAttachment("a.example.com", "notes.txt", "text/plain", bytes.NewReader([]byte("notes")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attachments, err := idb.ListAttachments("a.example.com")
+	if err != nil || len(attachments) != 2 || attachments[1].ContentType != "text/plain" {
+		t.Log("list:", attachments, err)
+		t.Fail()
+	}
+
+	r, err := idb.DownloadAttachment("a.example.com", a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloaded, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || !bytes.Equal(downloaded, content) {
+		t.Log("download:", len(downloaded), err)
+		t.Fail()
+	}
+
+	// truncated content fails the checksum
+	s.InjectFault(idbtest.Fault{Method: "GET", Malformed: true, Count: 1})
+	r, err = idb.DownloadAttachment("a.example.com", a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.ReadAll(r)
+	r.Close()
+	if _, ok := err.(*idbclient.ErrChecksum); !ok {
+		t.Log("download of truncated content:", err)
+		t.Fail()
+	}
+
+	err = idb.DeleteAttachment("a.example.com", a.ID)
+	if err != nil || s.Attachment("a.example.com", a.ID) != nil {
+		t.Log("delete:", err)
+		t.Fail()
+	}
+
+	_, err = idb.DownloadAttachment("a.example.com", a.ID)
+	if status(err) != http.StatusNotFound {
+		t.Log("download after delete:", err)
+		t.Fail()
+	}
+}
+
+func TestAttachmentStreaming(t *testing.T) {
+	s := idbtest.NewServer("secret")
+	defer s.Close()
+	s.Put(machine.Machine{Fqdn: "a.example.com"})
+	idb := s.Idb()
+
+	a, err := idb.UploadAttachment("a.example.com", "stream.txt", "text/plain", &streamReader{s: s, requests: s.Requests()})
+	if err != nil || a.Size != int64(3*len("chunk")) {
+		t.Log("streamed upload:", a, err)
+		t.Fail()
+	}
+
+	// the caller's reader isn't used anymore after a failed upload returned
+	s.InjectFault(idbtest.Fault{Method: "POST", Status: http.StatusServiceUnavailable, Count: 1})
+	r := &endlessReader{}
+	_, err = idb.UploadAttachment("a.example.com", "endless.bin", "", r)
+	if status(err) != http.StatusServiceUnavailable {
+		t.Log("upload with fault:", err)
+		t.Fail()
+	}
+
+	reads := r.reads.Load()
+	time.Sleep(20 * time.Millisecond)
+	if r.reads.Load() != reads {
+		t.Log("reader used after upload returned")
+		t.Fail()
+	}
+}
+
+func TestAttachmentID(t *testing.T) {
+	s := idbtest.NewServer("secret")
+	defer s.Close()
+	s.Put(machine.Machine{Fqdn: "a.example.com"})
+	idb := s.Idb()
+
+	n := s.Requests()
+
+	_, err := idb.DownloadAttachment("a.example.com", 0)
+	if _, ok := err.(*idbclient.ErrAttachmentID); !ok {
+		t.Log("download of attachment 0:", err)
+		t.Fail()
+	}
+
+	err = idb.DeleteAttachment("a.example.com", -1)
+	if _, ok := err.(*idbclient.ErrAttachmentID); !ok {
+		t.Log("delete of attachment -1:", err)
+		t.Fail()
+	}
+
+	if s.Requests() != n {
+		t.Log("invalid IDs were sent")
+		t.Fail()
+	}
+}
@@ -0,0 +1,121 @@
+// Package backup contains types shared by the backup importers which turn
+// the state of a backup system into machine backup updates for the IDB.
+package backup
+
+import (
+	"sort"
+	"time"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+// Level is the kind of a backup run.
+type Level int
+
+const (
+	LevelFull Level = iota
+	LevelIncremental
+	LevelDifferential
+)
+
+// Run is a single successfully completed backup run of a host.
+type Run struct {
+	// Fully qualified domain name of the backed up machine.
+	Fqdn string
+
+	// Kind of the run.
+	Level Level
+
+	// Completion time of the run.
+	Time time.Time
+
+	// Size of the run in bytes.
+	Size int64
+}
+
+// Summary holds the latest run of every level for a single host.
+type Summary struct {
+	Fqdn  string
+	Brand machine.BackupBrand
+
+	LastFull time.Time
+	LastInc  time.Time
+	LastDiff time.Time
+
+	SizeFull int64
+	SizeInc  int64
+	SizeDiff int64
+}
+
+// Add records r in the summary if it is at least as recent as the latest run of the same level.
+// Of runs with the same time, the one added last is kept.
+func (s *Summary) Add(r Run) {
+	var last *time.Time
+	var size *int64
+
+	switch r.Level {
+	case LevelFull:
+		last, size = &s.LastFull, &s.SizeFull
+	case LevelIncremental:
+		last, size = &s.LastInc, &s.SizeInc
+	case LevelDifferential:
+		last, size = &s.LastDiff, &s.SizeDiff
+	default:
+		return
+	}
+
+	if !r.Time.Before(*last) {
+		*last = r.Time
+		*size = r.Size
+	}
+}
+
+// Machine returns a machine containing only the backup fields of the summary,
+// ready to be submitted with Idb.UpdateMachine.
+func (s *Summary) Machine() *machine.Machine {
+	m := new(machine.Machine)
+	m.Backup(s.Fqdn, s.Brand, s.LastFull, s.LastInc, s.LastDiff, s.SizeFull, s.SizeInc, s.SizeDiff)
+	return m
+}
+
+// Summarize aggregates runs per host. The summaries are sorted by FQDN.
+func Summarize(brand machine.BackupBrand, runs []Run) []*Summary {
+	return SummarizeHosts(brand, nil, runs)
+}
+
+// SummarizeHosts is like Summarize, but also returns an empty summary for every host of fqdns
+// without runs, e.g. for hosts whose backups never completed.
+func SummarizeHosts(brand machine.BackupBrand, fqdns []string, runs []Run) []*Summary {
+	hosts := make(map[string]*Summary)
+
+	for _, fqdn := range fqdns {
+		hosts[fqdn] = &Summary{Fqdn: fqdn, Brand: brand}
+	}
+
+	for _, r := range runs {
+		s, ok := hosts[r.Fqdn]
+		if !ok {
+			s = &Summary{Fqdn: r.Fqdn, Brand: brand}
+			hosts[r.Fqdn] = s
+		}
+		s.Add(r)
+	}
+
+	summaries := make([]*Summary, 0, len(hosts))
+	for _, s := range hosts {
+		summaries = append(summaries, s)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Fqdn < summaries[j].Fqdn })
+
+	return summaries
+}
+
+// Machines converts summaries to machine backup updates.
+func Machines(summaries []*Summary) []*machine.Machine {
+	machines := make([]*machine.Machine, len(summaries))
+	for i, s := range summaries {
+		machines[i] = s.Machine()
+	}
+	return machines
+}
@@ -0,0 +1,208 @@
+// Package backuppc imports the per-host "backups" files of BackupPC, found in
+// the pc directory of the BackupPC data directory (e.g. /var/lib/backuppc/pc/<host>/backups).
+package backuppc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/idb-project/idbclient/backup"
+	"github.com/idb-project/idbclient/machine"
+)
+
+// Field positions in a backups record.
+const (
+	fieldNum = iota
+	fieldType
+	fieldStartTime
+	fieldEndTime
+	fieldNFiles
+	fieldSize
+	fieldCount
+)
+
+// Backup is a single record of a backups file.
+type Backup struct {
+	// Backup number.
+	Num int
+
+	// Backup type, e.g. "full", "incr", "partial" or "active".
+	Type string
+
+	StartTime time.Time
+	EndTime   time.Time
+
+	// Number of files in the backup.
+	NFiles int64
+
+	// Size of the backup in bytes.
+	Size int64
+}
+
+// Resolver maps a BackupPC host name to the FQDN of the machine.
+// Hosts resolved to an empty string are skipped.
+type Resolver func(host string) string
+
+// ErrRecord is returned if a record of a backups file can't be parsed.
+type ErrRecord struct {
+	line int
+	err  error
+}
+
+func (e *ErrRecord) Error() string {
+	return fmt.Sprintf("backuppc: line %v: %v", e.line, e.err)
+}
+
+// Parse reads the records of a backups file.
+func Parse(r io.Reader) ([]Backup, error) {
+	var backups []Backup
+
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		if strings.TrimSpace(s.Text()) == "" {
+			continue
+		}
+
+		b, err := parseRecord(strings.Split(s.Text(), "\t"))
+		if err != nil {
+			return nil, &ErrRecord{line, err}
+		}
+		backups = append(backups, b)
+	}
+
+	return backups, s.Err()
+}
+
+func parseRecord(fields []string) (Backup, error) {
+	var b Backup
+	var err error
+
+	if len(fields) < fieldCount {
+		return b, fmt.Errorf("%v fields, expected at least %v", len(fields), fieldCount)
+	}
+
+	b.Num, err = strconv.Atoi(fields[fieldNum])
+	if err != nil {
+		return b, err
+	}
+
+	b.Type = fields[fieldType]
+
+	start, err := strconv.ParseInt(fields[fieldStartTime], 10, 64)
+	if err != nil {
+		return b, err
+	}
+	b.StartTime = time.Unix(start, 0)
+
+	end, err := strconv.ParseInt(fields[fieldEndTime], 10, 64)
+	if err != nil {
+		return b, err
+	}
+	if end > 0 {
+		b.EndTime = time.Unix(end, 0)
+	}
+
+	b.NFiles, err = strconv.ParseInt(fields[fieldNFiles], 10, 64)
+	if err != nil {
+		return b, err
+	}
+
+	b.Size, err = strconv.ParseInt(fields[fieldSize], 10, 64)
+	if err != nil {
+		return b, err
+	}
+
+	return b, nil
+}
+
+// Run converts a completed full or incremental backup to a backup run of fqdn.
+// ok is false for partial or running backups.
+func (b Backup) Run(fqdn string) (r backup.Run, ok bool) {
+	switch b.Type {
+	case "full":
+		r.Level = backup.LevelFull
+	case "incr":
+		r.Level = backup.LevelIncremental
+	default:
+		return r, false
+	}
+
+	if b.EndTime.IsZero() {
+		return r, false
+	}
+
+	r.Fqdn = fqdn
+	r.Time = b.EndTime
+	r.Size = b.Size
+
+	return r, true
+}
+
+// Runs converts all completed backups of fqdn to backup runs.
+func Runs(fqdn string, backups []Backup) []backup.Run {
+	var runs []backup.Run
+	for _, b := range backups {
+		if r, ok := b.Run(fqdn); ok {
+			runs = append(runs, r)
+		}
+	}
+	return runs
+}
+
+// ReadHost reads the backups file of host in pcDir.
+func ReadHost(pcDir, host string) ([]Backup, error) {
+	f, err := os.Open(filepath.Join(pcDir, host, "backups"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Import reads the backups files of all hosts in pcDir and returns a backup update for every host.
+// If resolve is nil, host names are used as FQDNs. The backup fields of hosts without a completed
+// backup, including hosts without a backups file, are empty.
+func Import(pcDir string, resolve Resolver) ([]*machine.Machine, error) {
+	entries, err := ioutil.ReadDir(pcDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var fqdns []string
+	var runs []backup.Run
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		fqdn := e.Name()
+		if resolve != nil {
+			fqdn = resolve(fqdn)
+		}
+		if fqdn == "" {
+			continue
+		}
+
+		fqdns = append(fqdns, fqdn)
+
+		backups, err := ReadHost(pcDir, e.Name())
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, Runs(fqdn, backups)...)
+	}
+
+	return backup.Machines(backup.SummarizeHosts(machine.BackupBrandBackupPC, fqdns, runs)), nil
+}
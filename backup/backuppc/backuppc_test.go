@@ -0,0 +1,77 @@
+package backuppc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+var backupsFile = "0\tfull\t1478041502\t1478045102\t1000\t5000000\t900\t4000000\t100\t1000000\t0\t0\t0\t0\t3\t0\t0\t0\t-1\t1\trsync\t0\n" +
+	"1\tincr\t1478127902\t1478128502\t10\t2000\t5\t1000\t5\t1000\t0\t0\t0\t0\t3\t0\t0\t0\t-1\t1\trsync\t1\n" +
+	"2\tpartial\t1478214302\t1478214902\t10\t3000\t5\t1000\t5\t1000\t0\t0\t0\t0\t3\t0\t0\t0\t-1\t1\trsync\t0\n"
+
+func TestImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backuppc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, host := range []string{"a", "empty", "failed"} {
+		err = os.Mkdir(filepath.Join(dir, host), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, "a", "backups"), []byte(backupsFile), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only a partial backup
+	err = ioutil.WriteFile(filepath.Join(dir, "failed", "backups"), []byte(strings.SplitAfter(backupsFile, "\n")[2]), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ms, err := Import(dir, func(host string) string { return host + ".example.org" })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ms) != 3 {
+		t.Fatalf("Got %v machines, expected 3", len(ms))
+	}
+
+	m := ms[0]
+	if m.Fqdn != "a.example.org" || m.BackupBrand != machine.BackupBrandBackupPC {
+		t.Errorf("Unexpected machine %+v", m)
+	}
+	if !m.BackupLastFullRun.Equal(time.Unix(1478045102, 0)) || m.BackupLastFullSize != 5000000 {
+		t.Errorf("Unexpected full backup %v %v", m.BackupLastFullRun, m.BackupLastFullSize)
+	}
+	if !m.BackupLastIncRun.Equal(time.Unix(1478128502, 0)) || m.BackupLastIncSize != 2000 {
+		t.Errorf("Unexpected incremental backup %v %v", m.BackupLastIncRun, m.BackupLastIncSize)
+	}
+
+	// hosts without completed backups are reported with empty backup fields
+	for i, fqdn := range []string{"empty.example.org", "failed.example.org"} {
+		m := ms[i+1]
+		if m.Fqdn != fqdn || m.BackupBrand != machine.BackupBrandBackupPC || !m.BackupLastFullRun.IsZero() || !m.BackupLastIncRun.IsZero() || m.BackupLastFullSize != 0 {
+			t.Errorf("Unexpected machine without backups %+v", m)
+		}
+	}
+}
+
+func TestParseShortRecord(t *testing.T) {
+	_, err := Parse(strings.NewReader("0\tfull\t1478041502\n"))
+	if _, ok := err.(*ErrRecord); !ok {
+		t.Errorf("Got %v, expected ErrRecord", err)
+	}
+}
@@ -0,0 +1,399 @@
+// Package bacula imports Bacula job listings, either as printed by
+// "bconsole llist jobs" or "bconsole list jobs" or as a CSV export of the catalog Job table.
+//
+// The table printed by "list jobs" has no client column. Import it with a Resolver mapping jobs to
+// machines, e.g. Client for the listing of a single client printed by "list jobs client=<name>".
+package bacula
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/idb-project/idbclient/backup"
+	"github.com/idb-project/idbclient/machine"
+)
+
+var timeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", time.RFC3339}
+
+// Job is a single row of a Bacula job listing.
+type Job struct {
+	ID        int
+	Name      string
+	Client    string
+	Type      string
+	Level     string
+	Status    string
+	StartTime time.Time
+	EndTime   time.Time
+	Bytes     int64
+}
+
+// Resolver maps a job to the FQDN of the backed up machine.
+// Jobs resolved to an empty string are skipped.
+type Resolver func(j Job) string
+
+// ClientFqdn is the default Resolver. It uses the client name with the conventional "-fd" suffix removed.
+// Jobs without client are skipped.
+func ClientFqdn(j Job) string {
+	return strings.TrimSuffix(j.Client, "-fd")
+}
+
+// Client returns a Resolver mapping all jobs to fqdn, e.g. for the output of "list jobs client=<name>".
+func Client(fqdn string) Resolver {
+	return func(Job) string {
+		return fqdn
+	}
+}
+
+// ErrColumn is returned if a listing lacks a required column.
+type ErrColumn struct {
+	column string
+}
+
+func (e *ErrColumn) Error() string {
+	return fmt.Sprintf("bacula: listing has no %v column", e.column)
+}
+
+// ErrRow is returned if a row of a listing can't be parsed.
+type ErrRow struct {
+	row int
+	err error
+}
+
+func (e *ErrRow) Error() string {
+	return fmt.Sprintf("bacula: row %v: %v", e.row, e.err)
+}
+
+// Parse reads a job listing with times in the local time zone.
+func Parse(r io.Reader) ([]Job, error) {
+	return ParseInLocation(r, time.Local)
+}
+
+// ParseInLocation reads a job listing with times in loc.
+// The format (bconsole table, bconsole long listing or CSV) is detected from the table borders
+// or the "JobId:" lines printed by bconsole.
+func ParseInLocation(r io.Reader, loc *time.Location) ([]Job, error) {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var records [][]string
+	switch {
+	case isTable(buf):
+		records = tableRecords(buf)
+	case isLong(buf):
+		records = longRecords(buf)
+	default:
+		records, err = csv.NewReader(bytes.NewReader(buf)).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	return parseRecords(records, loc)
+}
+
+// isTable reports whether buf contains bconsole table borders.
+// bconsole prints some informational lines before the table.
+func isTable(buf []byte) bool {
+	s := bufio.NewScanner(bytes.NewReader(buf))
+	for s.Scan() {
+		if strings.HasPrefix(strings.TrimSpace(s.Text()), "+--") {
+			return true
+		}
+	}
+	return false
+}
+
+// tableRecords splits the rows of a bconsole table into cells.
+func tableRecords(buf []byte) [][]string {
+	var records [][]string
+
+	s := bufio.NewScanner(bytes.NewReader(buf))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if !strings.HasPrefix(line, "|") {
+			continue
+		}
+
+		cells := strings.Split(strings.Trim(line, "|"), "|")
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+		}
+		records = append(records, cells)
+	}
+
+	return records
+}
+
+// isLong reports whether buf is a long listing printed by "llist jobs".
+func isLong(buf []byte) bool {
+	s := bufio.NewScanner(bytes.NewReader(buf))
+	for s.Scan() {
+		if strings.HasPrefix(strings.TrimSpace(s.Text()), "JobId:") {
+			return true
+		}
+	}
+	return false
+}
+
+// longRecords converts the "Key: value" lines of a long listing to records, with the keys of
+// the first job as header. Every job starts with its JobId.
+func longRecords(buf []byte) [][]string {
+	var header []string
+	columns := make(map[string]int)
+	var records [][]string
+
+	s := bufio.NewScanner(bytes.NewReader(buf))
+	for s.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(s.Text()), ":")
+		if !ok || strings.Contains(key, " ") {
+			continue
+		}
+		if key == "JobId" {
+			records = append(records, nil)
+		}
+		if len(records) == 0 {
+			continue
+		}
+
+		i, ok := columns[key]
+		if !ok {
+			if len(records) > 1 {
+				// not part of the header
+				continue
+			}
+			i = len(header)
+			columns[key] = i
+			header = append(header, key)
+		}
+
+		rec := records[len(records)-1]
+		for len(rec) <= i {
+			rec = append(rec, "")
+		}
+		rec[i] = strings.TrimSpace(value)
+		records[len(records)-1] = rec
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+	return append([][]string{header}, records...)
+}
+
+// normalizeColumn maps the different spellings of column names to a common one.
+func normalizeColumn(c string) string {
+	c = strings.ToLower(c)
+	c = strings.NewReplacer(" ", "", "_", "", ".", "").Replace(c)
+
+	switch c {
+	case "job", "jobname":
+		return "name"
+	case "clientname":
+		return "client"
+	case "jobtype":
+		return "type"
+	case "joblevel":
+		return "level"
+	case "status":
+		return "jobstatus"
+	case "bytes":
+		return "jobbytes"
+	case "realendtime":
+		return "endtime"
+	}
+
+	return c
+}
+
+func parseRecords(records [][]string, loc *time.Location) ([]Job, error) {
+	columns := make(map[string]int)
+	for i, c := range records[0] {
+		c = normalizeColumn(c)
+		// prefer the first occurrence, e.g. EndTime over RealEndTime
+		if _, ok := columns[c]; !ok {
+			columns[c] = i
+		}
+	}
+
+	for _, c := range []string{"level", "jobstatus"} {
+		if _, ok := columns[c]; !ok {
+			return nil, &ErrColumn{c}
+		}
+	}
+	if _, ok := columns["starttime"]; !ok {
+		if _, ok := columns["endtime"]; !ok {
+			return nil, &ErrColumn{"starttime"}
+		}
+	}
+
+	jobs := make([]Job, 0, len(records)-1)
+	for n, rec := range records[1:] {
+		get := func(c string) string {
+			i, ok := columns[c]
+			if !ok || i >= len(rec) {
+				return ""
+			}
+			return rec[i]
+		}
+
+		j, err := parseJob(get, loc)
+		if err != nil {
+			return nil, &ErrRow{n + 1, err}
+		}
+		jobs = append(jobs, j)
+	}
+
+	return jobs, nil
+}
+
+func parseJob(get func(string) string, loc *time.Location) (Job, error) {
+	var j Job
+	var err error
+
+	if v := get("jobid"); v != "" {
+		j.ID, err = strconv.Atoi(strings.Replace(v, ",", "", -1))
+		if err != nil {
+			return j, err
+		}
+	}
+
+	if v := get("jobbytes"); v != "" {
+		j.Bytes, err = strconv.ParseInt(strings.Replace(v, ",", "", -1), 10, 64)
+		if err != nil {
+			return j, err
+		}
+	}
+
+	j.StartTime, err = parseTime(get("starttime"), loc)
+	if err != nil {
+		return j, err
+	}
+
+	j.EndTime, err = parseTime(get("endtime"), loc)
+	if err != nil {
+		return j, err
+	}
+
+	j.Name = get("name")
+	j.Client = get("client")
+	j.Type = get("type")
+	j.Level = get("level")
+	j.Status = get("jobstatus")
+
+	return j, nil
+}
+
+func parseTime(value string, loc *time.Location) (time.Time, error) {
+	// bconsole prints unset times as 0000-00-00 00:00:00 or leaves them empty
+	if value == "" || strings.HasPrefix(value, "0000-00-00") {
+		return time.Time{}, nil
+	}
+
+	var err error
+	for _, l := range timeLayouts {
+		var t time.Time
+		t, err = time.ParseInLocation(l, value, loc)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+// Successful reports whether j is a backup job which terminated normally, possibly with warnings.
+func (j Job) Successful() bool {
+	if j.Type != "" && j.Type != "B" && !strings.EqualFold(j.Type, "backup") {
+		return false
+	}
+
+	return j.Status == "T" || j.Status == "W"
+}
+
+// Run converts a successful job to a backup run of fqdn.
+// ok is false if the job wasn't successful or has an unknown level.
+func (j Job) Run(fqdn string) (r backup.Run, ok bool) {
+	if !j.Successful() {
+		return r, false
+	}
+
+	switch strings.ToUpper(j.Level) {
+	case "F", "FULL":
+		r.Level = backup.LevelFull
+	case "I", "INCREMENTAL":
+		r.Level = backup.LevelIncremental
+	case "D", "DIFFERENTIAL":
+		r.Level = backup.LevelDifferential
+	default:
+		return r, false
+	}
+
+	r.Fqdn = fqdn
+	r.Time = j.EndTime
+	if r.Time.IsZero() {
+		r.Time = j.StartTime
+	}
+	r.Size = j.Bytes
+
+	return r, !r.Time.IsZero()
+}
+
+// Runs converts all successful jobs to backup runs. If resolve is nil, ClientFqdn is used.
+// The runs are ordered by job ID, so of jobs with the same time, backup.Summary keeps the one
+// with the highest ID, regardless of the order of the listing.
+func Runs(jobs []Job, resolve Resolver) []backup.Run {
+	if resolve == nil {
+		resolve = ClientFqdn
+	}
+
+	jobs = append([]Job(nil), jobs...)
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+
+	var runs []backup.Run
+	for _, j := range jobs {
+		fqdn := resolve(j)
+		if fqdn == "" {
+			continue
+		}
+
+		if r, ok := j.Run(fqdn); ok {
+			runs = append(runs, r)
+		}
+	}
+
+	return runs
+}
+
+// Import reads a job listing and returns a backup update for every machine found in it.
+// If resolve is nil, ClientFqdn is used and the listing must have a client column.
+func Import(r io.Reader, resolve Resolver) ([]*machine.Machine, error) {
+	jobs, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolve == nil {
+		for _, j := range jobs {
+			if j.Client == "" {
+				return nil, &ErrColumn{"client"}
+			}
+		}
+	}
+
+	return backup.Machines(backup.Summarize(machine.BackupBrandBacula, Runs(jobs, resolve))), nil
+}
@@ -0,0 +1,140 @@
+package bacula
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/idb-project/idbclient/backup"
+	"github.com/idb-project/idbclient/machine"
+)
+
+var bconsoleListing = `Automatically selected Catalog: MyCatalog
+Using Catalog "MyCatalog"
++-------+--------------+---------------------+------+-------+----------+---------------+-----------+
+| JobId | Name         | StartTime           | Type | Level | JobFiles | JobBytes      | JobStatus |
++-------+--------------+---------------------+------+-------+----------+---------------+-----------+
+|     1 | backup-a     | 2016-11-01 23:05:02 | B    | F     |   12,345 | 1,000,000,000 | T         |
+|     2 | backup-a     | 2016-11-02 23:05:02 | B    | I     |      123 |        10,000 | T         |
+|     3 | backup-a     | 2016-11-03 23:05:02 | B    | I     |      456 |        20,000 | f         |
+|     4 | backup-a     | 2016-11-04 23:05:02 | R    | F     |      456 |        20,000 | T         |
+|     5 | backup-b     | 2016-11-04 23:05:02 | B    | D     |       42 |           500 | W         |
++-------+--------------+---------------------+------+-------+----------+---------------+-----------+
+`
+
+var csvListing = `JobId,Name,ClientName,StartTime,RealEndTime,Type,Level,JobBytes,JobStatus
+1,backup-a,a.example.org-fd,2016-11-01 23:05:02,2016-11-02 01:00:00,B,F,1000,T
+4,backup-a,a.example.org-fd,2016-11-08 23:05:02,2016-11-09 01:00:00,B,F,2500,T
+3,backup-a,a.example.org-fd,2016-11-05 23:05:02,2016-11-06 01:00:00,B,F,3000,T
+2,backup-a,a.example.org-fd,2016-11-08 23:05:02,2016-11-09 01:00:00,B,F,2000,T
+`
+
+var llistListing = `Automatically selected Catalog: MyCatalog
+Using Catalog "MyCatalog"
+           JobId: 1
+             Job: backup-a.2016-11-01_23.05.02_03
+            Name: backup-a
+            Type: B
+           Level: F
+      ClientName: a.example.org-fd
+       JobStatus: T
+       StartTime: 2016-11-01 23:05:02
+         EndTime: 2016-11-02 01:00:00
+        JobBytes: 1,000
+
+           JobId: 2
+             Job: backup-b.2016-11-02_23.05.02_04
+            Name: backup-b
+            Type: B
+           Level: I
+      ClientName: b.example.org-fd
+       JobStatus: T
+       StartTime: 2016-11-02 23:05:02
+         EndTime: 2016-11-03 01:00:00
+        JobBytes: 20
+`
+
+// jobClients maps the job names of bconsoleListing to machines.
+func jobClients(j Job) string {
+	return map[string]string{"backup-a": "a.example.org", "backup-b": "b.example.org"}[j.Name]
+}
+
+func importTest(t *testing.T, listing string, resolve Resolver) []*machine.Machine {
+	jobs, err := ParseInLocation(strings.NewReader(listing), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return backup.Machines(backup.Summarize(machine.BackupBrandBacula, Runs(jobs, resolve)))
+}
+
+func TestImportBconsole(t *testing.T) {
+	ms := importTest(t, bconsoleListing, jobClients)
+	if len(ms) != 2 {
+		t.Fatalf("Got %v machines, expected 2", len(ms))
+	}
+
+	a := ms[0]
+	if a.Fqdn != "a.example.org" || a.BackupBrand != machine.BackupBrandBacula {
+		t.Errorf("Unexpected machine %+v", a)
+	}
+	if a.BackupLastFullRun != time.Date(2016, 11, 1, 23, 5, 2, 0, time.UTC) || a.BackupLastFullSize != 1000000000 {
+		t.Errorf("Unexpected full backup %v %v", a.BackupLastFullRun, a.BackupLastFullSize)
+	}
+	if a.BackupLastIncRun != time.Date(2016, 11, 2, 23, 5, 2, 0, time.UTC) || a.BackupLastIncSize != 10000 {
+		t.Errorf("Unexpected incremental backup %v %v", a.BackupLastIncRun, a.BackupLastIncSize)
+	}
+
+	b := ms[1]
+	if b.Fqdn != "b.example.org" || b.BackupLastDiffSize != 500 || !b.BackupLastFullRun.IsZero() {
+		t.Errorf("Unexpected machine %+v", b)
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	ms := importTest(t, csvListing, nil)
+	if len(ms) != 1 {
+		t.Fatalf("Got %v machines, expected 1", len(ms))
+	}
+
+	if ms[0].Fqdn != "a.example.org" {
+		t.Errorf("Got fqdn %v", ms[0].Fqdn)
+	}
+	// of the jobs with the same time, the one with the highest ID is kept, even if listed first
+	if ms[0].BackupLastFullRun != time.Date(2016, 11, 9, 1, 0, 0, 0, time.UTC) || ms[0].BackupLastFullSize != 2500 {
+		t.Errorf("Unexpected full backup %v %v", ms[0].BackupLastFullRun, ms[0].BackupLastFullSize)
+	}
+}
+
+func TestMissingColumn(t *testing.T) {
+	_, err := Parse(strings.NewReader("JobId,Name\n1,foo\n"))
+	if _, ok := err.(*ErrColumn); !ok {
+		t.Errorf("Got %v, expected ErrColumn", err)
+	}
+}
+
+func TestImportLlist(t *testing.T) {
+	ms := importTest(t, llistListing, nil)
+	if len(ms) != 2 {
+		t.Fatalf("Got %v machines, expected 2", len(ms))
+	}
+
+	if ms[0].Fqdn != "a.example.org" || ms[0].BackupLastFullRun != time.Date(2016, 11, 2, 1, 0, 0, 0, time.UTC) || ms[0].BackupLastFullSize != 1000 {
+		t.Errorf("Unexpected machine %+v", ms[0])
+	}
+	if ms[1].Fqdn != "b.example.org" || ms[1].BackupLastIncSize != 20 {
+		t.Errorf("Unexpected machine %+v", ms[1])
+	}
+}
+
+func TestImportWithoutClient(t *testing.T) {
+	_, err := Import(strings.NewReader(bconsoleListing), nil)
+	if e, ok := err.(*ErrColumn); !ok || e.column != "client" {
+		t.Errorf("Got %v, expected ErrColumn", err)
+	}
+
+	ms, err := Import(strings.NewReader(bconsoleListing), Client("a.example.org"))
+	if err != nil || len(ms) != 1 || ms[0].Fqdn != "a.example.org" {
+		t.Errorf("Unexpected import with client %v %v", ms, err)
+	}
+}
@@ -0,0 +1,199 @@
+// Package filebackup scans directory trees of backup archives for machines using BackupBrandFile.
+package filebackup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/idb-project/idbclient/backup"
+	"github.com/idb-project/idbclient/machine"
+)
+
+// DefaultPatterns match archives like <fqdn>/full-2016-11-18.tar.gz.
+var DefaultPatterns = map[backup.Level]string{
+	backup.LevelFull:         "{fqdn}/full-{date}.*",
+	backup.LevelIncremental:  "{fqdn}/inc-{date}.*",
+	backup.LevelDifferential: "{fqdn}/diff-{date}.*",
+}
+
+// DefaultDateLayout is the layout of the {date} placeholder used by NewScanner.
+const DefaultDateLayout = "2006-01-02"
+
+// ErrPattern is returned if a naming pattern is invalid.
+type ErrPattern struct {
+	pattern string
+	reason  string
+}
+
+func (e *ErrPattern) Error() string {
+	return fmt.Sprintf("filebackup: invalid pattern %q: %v", e.pattern, e.reason)
+}
+
+// Scanner finds backup archives below Root.
+//
+// Patterns are slash separated paths relative to Root. They must contain the placeholder {fqdn}
+// and may contain {date} and the wildcard *, which doesn't match slashes.
+// The date of an archive is parsed from {date} with DateLayout in Location. If the pattern
+// has no {date} or the date can't be parsed, the modification time of the file is used.
+// Archives of the same level and date are considered parts of one run and their sizes are summed.
+type Scanner struct {
+	Root       string
+	Patterns   map[backup.Level]string
+	DateLayout string
+	Location   *time.Location
+}
+
+// NewScanner creates a Scanner for root using DefaultPatterns and DefaultDateLayout in the local time zone.
+func NewScanner(root string) *Scanner {
+	s := new(Scanner)
+	s.Root = root
+	s.Patterns = make(map[backup.Level]string)
+	for l, p := range DefaultPatterns {
+		s.Patterns[l] = p
+	}
+	s.DateLayout = DefaultDateLayout
+	s.Location = time.Local
+	return s
+}
+
+type pattern struct {
+	level backup.Level
+	re    *regexp.Regexp
+	fqdn  int
+	date  int
+}
+
+var placeholder = regexp.MustCompile(`\{fqdn\}|\{date\}|\*`)
+
+func compilePattern(level backup.Level, p string) (*pattern, error) {
+	c := &pattern{level: level, fqdn: -1, date: -1}
+
+	expr := "^"
+	group := 0
+	last := 0
+	for _, loc := range placeholder.FindAllStringIndex(p, -1) {
+		expr += regexp.QuoteMeta(p[last:loc[0]])
+		last = loc[1]
+
+		switch p[loc[0]:loc[1]] {
+		case "{fqdn}":
+			if c.fqdn >= 0 {
+				return nil, &ErrPattern{p, "{fqdn} occurs more than once"}
+			}
+			group++
+			c.fqdn = group
+			expr += "([^/]+?)"
+		case "{date}":
+			if c.date >= 0 {
+				return nil, &ErrPattern{p, "{date} occurs more than once"}
+			}
+			group++
+			c.date = group
+			expr += "([^/]+?)"
+		case "*":
+			expr += "[^/]*"
+		}
+	}
+	expr += regexp.QuoteMeta(p[last:]) + "$"
+
+	if c.fqdn < 0 {
+		return nil, &ErrPattern{p, "{fqdn} is missing"}
+	}
+
+	var err error
+	c.re, err = regexp.Compile(expr)
+	if err != nil {
+		return nil, &ErrPattern{p, err.Error()}
+	}
+
+	return c, nil
+}
+
+// Runs returns a backup run for every archive below Root matching one of the patterns. Archives of the
+// same machine and level with the same time are considered parts of one archive and their sizes are summed.
+func (s *Scanner) Runs() ([]backup.Run, error) {
+	var patterns []*pattern
+	for _, l := range []backup.Level{backup.LevelFull, backup.LevelIncremental, backup.LevelDifferential} {
+		p, ok := s.Patterns[l]
+		if !ok {
+			continue
+		}
+
+		c, err := compilePattern(l, p)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, c)
+	}
+
+	loc := s.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	type part struct {
+		fqdn  string
+		level backup.Level
+		t     time.Time
+	}
+	parts := make(map[part]int)
+
+	var runs []backup.Run
+	err := filepath.Walk(s.Root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.Root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		for _, p := range patterns {
+			match := p.re.FindStringSubmatch(rel)
+			if match == nil {
+				continue
+			}
+
+			r := backup.Run{Fqdn: match[p.fqdn], Level: p.level, Time: info.ModTime(), Size: info.Size()}
+			if p.date >= 0 {
+				if t, err := time.ParseInLocation(s.DateLayout, match[p.date], loc); err == nil {
+					r.Time = t
+				}
+			}
+
+			k := part{r.Fqdn, r.Level, r.Time.UTC()}
+			if i, ok := parts[k]; ok {
+				runs[i].Size += r.Size
+				break
+			}
+			parts[k] = len(runs)
+			runs = append(runs, r)
+			break
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+// Scan returns a backup update for every machine with archives below Root.
+func (s *Scanner) Scan() ([]*machine.Machine, error) {
+	runs, err := s.Runs()
+	if err != nil {
+		return nil, err
+	}
+
+	return backup.Machines(backup.Summarize(machine.BackupBrandFile, runs)), nil
+}
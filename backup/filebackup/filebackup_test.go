@@ -0,0 +1,80 @@
+package filebackup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/idb-project/idbclient/backup"
+	"github.com/idb-project/idbclient/machine"
+)
+
+var archives = map[string]int{
+	"a.example.org/full-2016-11-01.tar.gz":     100,
+	"a.example.org/full-2016-11-08.tar.gz.001": 200,
+	"a.example.org/full-2016-11-08.tar.gz.002": 50,
+	"a.example.org/inc-2016-11-09.tar.gz":      10,
+	"a.example.org/README":                     1,
+	"b.example.org/diff-2016-11-10.tar.gz":     20,
+}
+
+func TestScan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filebackup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, size := range archives {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = ioutil.WriteFile(path, make([]byte, size), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewScanner(dir)
+	s.Location = time.UTC
+
+	ms, err := s.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ms) != 2 {
+		t.Fatalf("Got %v machines, expected 2", len(ms))
+	}
+
+	a := ms[0]
+	if a.Fqdn != "a.example.org" || a.BackupBrand != machine.BackupBrandFile {
+		t.Errorf("Unexpected machine %+v", a)
+	}
+	if a.BackupLastFullRun != time.Date(2016, 11, 8, 0, 0, 0, 0, time.UTC) || a.BackupLastFullSize != 250 {
+		t.Errorf("Unexpected full backup %v %v", a.BackupLastFullRun, a.BackupLastFullSize)
+	}
+	if a.BackupLastIncRun != time.Date(2016, 11, 9, 0, 0, 0, 0, time.UTC) || a.BackupLastIncSize != 10 {
+		t.Errorf("Unexpected incremental backup %v %v", a.BackupLastIncRun, a.BackupLastIncSize)
+	}
+
+	b := ms[1]
+	if b.Fqdn != "b.example.org" || b.BackupLastDiffSize != 20 {
+		t.Errorf("Unexpected machine %+v", b)
+	}
+}
+
+func TestInvalidPattern(t *testing.T) {
+	s := NewScanner(".")
+	s.Patterns[backup.LevelFull] = "full-{date}.tar.gz"
+
+	_, err := s.Runs()
+	if _, ok := err.(*ErrPattern); !ok {
+		t.Errorf("Got %v, expected ErrPattern", err)
+	}
+}
@@ -0,0 +1,170 @@
+// Package policy checks the backup data of machines against maximum backup ages.
+package policy
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/idb-project/idbclient"
+	"github.com/idb-project/idbclient/machine"
+)
+
+// Limit is the maximum age of a backup kind. A zero duration disables the respective check.
+type Limit struct {
+	Warning  time.Duration
+	Critical time.Duration
+}
+
+// Policy contains the maximum ages per backup kind.
+type Policy struct {
+	Full Limit
+	Inc  Limit
+	Diff Limit
+
+	// Status of machines with BackupTypeNo, i.e. machines without backup which are not
+	// marked as not needing one.
+	NoBackup Status
+}
+
+// Result is the evaluation result of a single machine.
+type Result struct {
+	Fqdn    string
+	Status  Status
+	Reasons []string
+}
+
+func (r *Result) add(s Status, format string, a ...interface{}) {
+	if s > r.Status {
+		r.Status = s
+	}
+	r.Reasons = append(r.Reasons, fmt.Sprintf(format, a...))
+}
+
+// Evaluate classifies m at time now.
+func (p *Policy) Evaluate(m *machine.Machine, now time.Time) Result {
+	r := Result{Fqdn: m.Fqdn, Status: StatusOK}
+
+	switch m.BackupType {
+	case machine.BackupTypeNotNeeded:
+		r.Reasons = append(r.Reasons, "backup not needed")
+		return r
+	case machine.BackupTypeNotResponsible:
+		r.Reasons = append(r.Reasons, "not responsible for backup")
+		return r
+	case machine.BackupTypeNo:
+		r.add(p.NoBackup, "no backup configured")
+		return r
+	}
+
+	p.check(&r, "full", p.Full, m.BackupLastFullRun, now)
+	p.check(&r, "incremental", p.Inc, m.BackupLastIncRun, now)
+	p.check(&r, "differential", p.Diff, m.BackupLastDiffRun, now)
+
+	return r
+}
+
+func (p *Policy) check(r *Result, kind string, l Limit, last time.Time, now time.Time) {
+	if l.Warning == 0 && l.Critical == 0 {
+		return
+	}
+
+	if last.IsZero() {
+		r.add(StatusCritical, "no %v backup recorded", kind)
+		return
+	}
+
+	age := now.Sub(last).Truncate(time.Second)
+	switch {
+	case l.Critical != 0 && age > l.Critical:
+		r.add(StatusCritical, "last %v backup is %v old, critical after %v", kind, age, l.Critical)
+	case l.Warning != 0 && age > l.Warning:
+		r.add(StatusWarning, "last %v backup is %v old, warning after %v", kind, age, l.Warning)
+	}
+}
+
+// Report contains the results of all evaluated machines.
+type Report struct {
+	Time    time.Time
+	Results []Result
+}
+
+// EvaluateAll classifies all machines at time now, except deleted ones. The results are sorted by
+// status, worst first, and FQDN.
+func (p *Policy) EvaluateAll(machines []machine.Machine, now time.Time) *Report {
+	report := &Report{Time: now, Results: make([]Result, 0, len(machines))}
+
+	for i := range machines {
+		if !machines[i].DeletedAt.IsZero() {
+			continue
+		}
+		report.Results = append(report.Results, p.Evaluate(&machines[i], now))
+	}
+
+	sort.SliceStable(report.Results, func(i, j int) bool {
+		ri, rj := report.Results[i], report.Results[j]
+		if ri.Status != rj.Status {
+			return ri.Status > rj.Status
+		}
+		return ri.Fqdn < rj.Fqdn
+	})
+
+	return report
+}
+
+// Status returns the worst status of all results.
+func (r *Report) Status() Status {
+	s := StatusOK
+	for _, v := range r.Results {
+		if v.Status > s {
+			s = v.Status
+		}
+	}
+	return s
+}
+
+// Count returns the number of results with status s.
+func (r *Report) Count(s Status) int {
+	n := 0
+	for _, v := range r.Results {
+		if v.Status == s {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteTo writes a human readable report to w.
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+
+	n, err := fmt.Fprintf(w, "Backup report %v: %v OK, %v warning, %v critical\n",
+		r.Time.Format(time.RFC3339), r.Count(StatusOK), r.Count(StatusWarning), r.Count(StatusCritical))
+	written += int64(n)
+	if err != nil {
+		return written, err
+	}
+
+	for _, v := range r.Results {
+		for _, reason := range v.Reasons {
+			n, err = fmt.Fprintf(w, "%-8v %v: %v\n", v.Status, v.Fqdn, reason)
+			written += int64(n)
+			if err != nil {
+				return written, err
+			}
+		}
+	}
+
+	return written, nil
+}
+
+// Check evaluates all machines of the IDB, except deleted ones.
+func (p *Policy) Check(idb *idbclient.Idb) (*Report, error) {
+	machines, err := idb.ListMachines()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.EvaluateAll(machines, time.Now()), nil
+}
@@ -0,0 +1,68 @@
+package policy
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+var now = time.Date(2016, 11, 18, 12, 0, 0, 0, time.UTC)
+
+var testPolicy = Policy{
+	Full:     Limit{Warning: 8 * 24 * time.Hour, Critical: 15 * 24 * time.Hour},
+	Inc:      Limit{Critical: 2 * 24 * time.Hour},
+	NoBackup: StatusWarning,
+}
+
+var evaluateTests = []struct {
+	m      machine.Machine
+	status Status
+}{
+	{machine.Machine{Fqdn: "ok", BackupType: machine.BackupTypeYes, BackupLastFullRun: now.AddDate(0, 0, -3), BackupLastIncRun: now.AddDate(0, 0, -1)}, StatusOK},
+	{machine.Machine{Fqdn: "warning", BackupType: machine.BackupTypeYes, BackupLastFullRun: now.AddDate(0, 0, -10), BackupLastIncRun: now.AddDate(0, 0, -1)}, StatusWarning},
+	{machine.Machine{Fqdn: "critical", BackupType: machine.BackupTypeYes, BackupLastFullRun: now.AddDate(0, 0, -3), BackupLastIncRun: now.AddDate(0, 0, -3)}, StatusCritical},
+	{machine.Machine{Fqdn: "missing", BackupType: machine.BackupTypeYes, BackupLastFullRun: now.AddDate(0, 0, -3)}, StatusCritical},
+	{machine.Machine{Fqdn: "notneeded", BackupType: machine.BackupTypeNotNeeded}, StatusOK},
+	{machine.Machine{Fqdn: "notresponsible", BackupType: machine.BackupTypeNotResponsible}, StatusOK},
+	{machine.Machine{Fqdn: "none", BackupType: machine.BackupTypeNo}, StatusWarning},
+}
+
+func TestEvaluate(t *testing.T) {
+	for _, v := range evaluateTests {
+		r := testPolicy.Evaluate(&v.m, now)
+		if r.Status != v.status {
+			t.Errorf("%v: got %v, expected %v (%v)", v.m.Fqdn, r.Status, v.status, r.Reasons)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	var machines []machine.Machine
+	for _, v := range evaluateTests {
+		machines = append(machines, v.m)
+	}
+	// deleted machines without backups are not reported
+	machines = append(machines, machine.Machine{Fqdn: "deleted", BackupType: machine.BackupTypeYes, DeletedAt: now.AddDate(0, 0, -30)})
+
+	r := testPolicy.EvaluateAll(machines, now)
+	if len(r.Results) != len(evaluateTests) {
+		t.Errorf("Got %v results, expected %v", len(r.Results), len(evaluateTests))
+	}
+	if r.Status() != StatusCritical {
+		t.Errorf("Got %v, expected CRITICAL", r.Status())
+	}
+	if r.Results[0].Fqdn != "critical" || r.Count(StatusWarning) != 2 {
+		t.Errorf("Unexpected results %+v", r.Results)
+	}
+
+	var buf bytes.Buffer
+	_, err := r.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("CRITICAL critical: last incremental backup is 72h0m0s old, critical after 48h0m0s")) {
+		t.Errorf("Unexpected report:\n%v", buf.String())
+	}
+}
@@ -0,0 +1,12 @@
+//go:generate stringer -type=Status -linecomment
+
+package policy
+
+// Status is the classification of a machine. The numeric values match the exit codes of monitoring plugins.
+type Status int
+
+const (
+	StatusOK       Status = iota // OK
+	StatusWarning                // WARNING
+	StatusCritical               // CRITICAL
+)
@@ -0,0 +1,243 @@
+package idbclient
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+// CacheStats contains counters of a Cache.
+type CacheStats struct {
+	// Lookups answered from a cached machine.
+	Hits int64
+
+	// Lookups answered from a cached "not found" response.
+	NegativeHits int64
+
+	// Lookups which requested the IDB.
+	Misses int64
+
+	// Lookups which waited for an identical lookup in flight instead of requesting the IDB.
+	Collapsed int64
+
+	// Entries removed by Invalidate or replaced after updates.
+	Invalidations int64
+
+	// Current number of entries, including expired ones not yet removed.
+	Entries int
+}
+
+// Cache caches GetMachine results per FQDN. Concurrent lookups of the same FQDN are collapsed into a
+// single request. Set it on Idb.Cache to enable caching. The zero value is an empty cache with zero
+// TTLs; usually it is created with NewCache. A Cache is safe for concurrent use.
+type Cache struct {
+	// TTL of cached machines.
+	TTL time.Duration
+
+	// TTL of cached "not found" responses. Zero disables negative caching.
+	NegativeTTL time.Duration
+
+	mu       sync.Mutex
+	entries  map[string]*cacheEntry
+	inflight map[string]*cacheCall
+	stats    CacheStats
+
+	// expired entries are removed at nextSweep
+	nextSweep time.Time
+
+	// now is replaced in tests.
+	now func() time.Time
+}
+
+type cacheEntry struct {
+	m       *machine.Machine
+	err     error
+	expires time.Time
+}
+
+type cacheCall struct {
+	done chan struct{}
+	m    *machine.Machine
+	err  error
+
+	// stale is set if the entry was replaced or invalidated while loading. The result is still
+	// returned to the waiting lookups, but not cached.
+	stale bool
+}
+
+// errLoadPanic is returned to lookups waiting for a load which panicked.
+var errLoadPanic = errors.New("idbclient: machine lookup panicked")
+
+// NewCache creates a Cache keeping machines for ttl and "not found" responses for negativeTTL.
+func NewCache(ttl, negativeTTL time.Duration) *Cache {
+	return &Cache{
+		TTL:         ttl,
+		NegativeTTL: negativeTTL,
+	}
+}
+
+// init initializes the maps of a zero Cache. c.mu must be held.
+func (c *Cache) init() {
+	if c.entries == nil {
+		c.entries = make(map[string]*cacheEntry)
+		c.inflight = make(map[string]*cacheCall)
+	}
+	if c.now == nil {
+		c.now = time.Now
+	}
+}
+
+// sweep removes expired entries, at most once per TTL. c.mu must be held.
+func (c *Cache) sweep(now time.Time) {
+	if now.Before(c.nextSweep) {
+		return
+	}
+
+	for fqdn, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, fqdn)
+		}
+	}
+	c.nextSweep = now.Add(c.TTL)
+}
+
+// markStale prevents loads in flight for fqdn from being cached. c.mu must be held.
+func (c *Cache) markStale(fqdn string) {
+	if call, ok := c.inflight[fqdn]; ok {
+		call.stale = true
+	}
+}
+
+// copyMachine returns a copy of m, so callers can't modify cached machines.
+func copyMachine(m *machine.Machine) *machine.Machine {
+	if m == nil {
+		return nil
+	}
+
+	c := *m
+	if m.Nics != nil {
+		c.Nics = make([]machine.Nic, len(m.Nics))
+		copy(c.Nics, m.Nics)
+	}
+	return &c
+}
+
+// notFound reports whether err is a 404 response.
+func notFound(err error) bool {
+	s, ok := err.(*ErrStatus)
+	return ok && s.Status() == http.StatusNotFound
+}
+
+// get returns the cached machine for fqdn, or calls load once for all concurrent lookups of fqdn.
+func (c *Cache) get(fqdn string, load func() (*machine.Machine, error)) (*machine.Machine, error) {
+	c.mu.Lock()
+	c.init()
+
+	now := c.now()
+	if e, ok := c.entries[fqdn]; ok {
+		if now.Before(e.expires) {
+			if e.err != nil {
+				c.stats.NegativeHits++
+			} else {
+				c.stats.Hits++
+			}
+			c.mu.Unlock()
+			return copyMachine(e.m), e.err
+		}
+		delete(c.entries, fqdn)
+	}
+	c.sweep(now)
+
+	if call, ok := c.inflight[fqdn]; ok {
+		c.stats.Collapsed++
+		c.mu.Unlock()
+
+		<-call.done
+		return copyMachine(call.m), call.err
+	}
+
+	call := &cacheCall{done: make(chan struct{})}
+	c.inflight[fqdn] = call
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	c.load(fqdn, call, load)
+
+	return copyMachine(call.m), call.err
+}
+
+// load calls load and finishes call, also if load panics.
+func (c *Cache) load(fqdn string, call *cacheCall, load func() (*machine.Machine, error)) {
+	loaded := false
+	defer func() {
+		c.mu.Lock()
+		delete(c.inflight, fqdn)
+		switch {
+		case !loaded || call.stale:
+		case call.err == nil:
+			c.entries[fqdn] = &cacheEntry{m: call.m, expires: c.now().Add(c.TTL)}
+		case notFound(call.err) && c.NegativeTTL > 0:
+			c.entries[fqdn] = &cacheEntry{err: call.err, expires: c.now().Add(c.NegativeTTL)}
+		}
+		c.mu.Unlock()
+
+		close(call.done)
+	}()
+
+	call.err = errLoadPanic
+	call.m, call.err = load()
+	loaded = true
+}
+
+// set replaces the entry for m.Fqdn, e.g. with the response of an update.
+func (c *Cache) set(m *machine.Machine) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+
+	if _, ok := c.entries[m.Fqdn]; ok {
+		c.stats.Invalidations++
+	}
+	c.markStale(m.Fqdn)
+	c.entries[m.Fqdn] = &cacheEntry{m: copyMachine(m), expires: c.now().Add(c.TTL)}
+}
+
+// Invalidate removes the entry for fqdn.
+func (c *Cache) Invalidate(fqdn string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+
+	if _, ok := c.entries[fqdn]; ok {
+		c.stats.Invalidations++
+		delete(c.entries, fqdn)
+	}
+	c.markStale(fqdn)
+}
+
+// Purge removes all entries.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.init()
+
+	c.stats.Invalidations += int64(len(c.entries))
+	c.entries = make(map[string]*cacheEntry)
+	for fqdn := range c.inflight {
+		c.markStale(fqdn)
+	}
+}
+
+// Stats returns the current counters.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.stats
+	s.Entries = len(c.entries)
+	return s
+}
@@ -0,0 +1,233 @@
+package idbclient
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCache(time.Minute, time.Second)
+	c.now = func() time.Time { return now }
+
+	loads := 0
+	load := func() (*machine.Machine, error) {
+		loads++
+		return &machine.Machine{Fqdn: "a.example.com", Cores: loads}, nil
+	}
+
+	m, _ := c.get("a.example.com", load)
+	m.Cores = 42
+	m, _ = c.get("a.example.com", load)
+	if loads != 1 || m.Cores != 1 {
+		t.Log("cached machine not returned unmodified", loads, m.Cores)
+		t.Fail()
+	}
+
+	now = now.Add(2 * time.Minute)
+	m, _ = c.get("a.example.com", load)
+	if loads != 2 || m.Cores != 2 {
+		t.Log("expired entry not reloaded", loads, m.Cores)
+		t.Fail()
+	}
+
+	c.set(&machine.Machine{Fqdn: "a.example.com", Cores: 8})
+	m, _ = c.get("a.example.com", load)
+	if loads != 2 || m.Cores != 8 {
+		t.Log("updated entry not returned", loads, m.Cores)
+		t.Fail()
+	}
+
+	c.Invalidate("a.example.com")
+	c.get("a.example.com", load)
+	if loads != 3 {
+		t.Log("invalidated entry not reloaded", loads)
+		t.Fail()
+	}
+
+	missing := func() (*machine.Machine, error) {
+		loads++
+		return nil, newErrStatus(http.StatusNotFound, http.StatusOK, nil)
+	}
+
+	for n := 0; n < 2; n++ {
+		if _, err := c.get("b.example.com", missing); !notFound(err) {
+			t.Log("expected not found", err)
+			t.Fail()
+		}
+	}
+	if loads != 4 {
+		t.Log("not found response not cached", loads)
+		t.Fail()
+	}
+
+	s := c.Stats()
+	if s.Hits != 2 || s.NegativeHits != 1 || s.Misses != 4 || s.Invalidations != 2 || s.Entries != 2 {
+		t.Logf("unexpected stats %+v", s)
+		t.Fail()
+	}
+}
+
+func TestCacheCollapse(t *testing.T) {
+	c := NewCache(time.Minute, 0)
+
+	release := make(chan struct{})
+	var mu sync.Mutex
+	loads := 0
+	load := func() (*machine.Machine, error) {
+		mu.Lock()
+		loads++
+		mu.Unlock()
+		<-release
+		return &machine.Machine{Fqdn: "a.example.com"}, nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	for k := 0; k < n; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.get("a.example.com", load)
+		}()
+	}
+
+	// wait until all lookups are either in flight or collapsed
+	for {
+		s := c.Stats()
+		if s.Misses+s.Collapsed == n {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if loads != 1 {
+		t.Log("concurrent lookups not collapsed", loads)
+		t.Fail()
+	}
+}
+
+func TestCacheLiteral(t *testing.T) {
+	c := &Cache{TTL: time.Minute}
+
+	c.Invalidate("a.example.com")
+	c.set(&machine.Machine{Fqdn: "a.example.com", Cores: 2})
+	m, err := c.get("a.example.com", nil)
+	if err != nil || m.Cores != 2 {
+		t.Log("cache literal:", m, err)
+		t.Fail()
+	}
+}
+
+func TestCacheStaleLoad(t *testing.T) {
+	c := NewCache(time.Minute, 0)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	load := func() (*machine.Machine, error) {
+		close(started)
+		<-release
+		return &machine.Machine{Fqdn: "a.example.com", Cores: 1}, nil
+	}
+
+	done := make(chan *machine.Machine)
+	go func() {
+		m, _ := c.get("a.example.com", load)
+		done <- m
+	}()
+
+	// an update finishes while the lookup is in flight
+	<-started
+	c.set(&machine.Machine{Fqdn: "a.example.com", Cores: 8})
+	close(release)
+
+	if m := <-done; m.Cores != 1 {
+		t.Log("lookup didn't return its own result", m.Cores)
+		t.Fail()
+	}
+
+	m, _ := c.get("a.example.com", nil)
+	if m.Cores != 8 {
+		t.Log("stale lookup replaced updated entry", m.Cores)
+		t.Fail()
+	}
+}
+
+func TestCacheSweep(t *testing.T) {
+	now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewCache(time.Minute, time.Second)
+	c.now = func() time.Time { return now }
+
+	missing := func() (*machine.Machine, error) {
+		return nil, newErrStatus(http.StatusNotFound, http.StatusOK, nil)
+	}
+
+	for _, fqdn := range []string{"a.example.com", "b.example.com", "c.example.com"} {
+		c.get(fqdn, missing)
+	}
+	if s := c.Stats(); s.Entries != 3 {
+		t.Log("negative entries not cached", s.Entries)
+		t.Fail()
+	}
+
+	now = now.Add(2 * time.Minute)
+	c.get("d.example.com", missing)
+	if s := c.Stats(); s.Entries != 1 {
+		t.Log("expired entries not removed", s.Entries)
+		t.Fail()
+	}
+}
+
+func TestCachePanic(t *testing.T) {
+	c := NewCache(time.Minute, 0)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	load := func() (*machine.Machine, error) {
+		close(started)
+		<-release
+		panic("load failed")
+	}
+
+	go func() {
+		defer func() { recover() }()
+		c.get("a.example.com", load)
+	}()
+
+	<-started
+	waiter := make(chan error)
+	go func() {
+		_, err := c.get("a.example.com", nil)
+		waiter <- err
+	}()
+
+	// wait until the second lookup is collapsed into the first
+	for c.Stats().Collapsed == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	select {
+	case err := <-waiter:
+		if err != errLoadPanic {
+			t.Log("unexpected error", err)
+			t.Fail()
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiting lookup hangs after panic")
+	}
+
+	m, err := c.get("a.example.com", func() (*machine.Machine, error) {
+		return &machine.Machine{Fqdn: "a.example.com"}, nil
+	})
+	if err != nil || m == nil {
+		t.Log("lookup after panic:", m, err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,74 @@
+// Command idb-exporter serves fleet metrics from the IDB for Prometheus.
+//
+// The IDB URL and API token are read from the flags -url and -token or the environment variables
+// IDB_URL and IDB_TOKEN. The time zone of the IDB and whether it uses RFC 3339 times are configured
+// the same way with -timezone and -rfc3339 or IDB_TIMEZONE and IDB_RFC3339.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/idb-project/idbclient"
+	"github.com/idb-project/idbclient/exporter"
+	"github.com/idb-project/idbclient/machine"
+)
+
+// envBool returns the boolean value of the environment variable key, false if it is unset.
+func envBool(key string) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return false
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Fatalf("%v: %v", key, err)
+	}
+	return b
+}
+
+func main() {
+	apiURL := flag.String("url", os.Getenv("IDB_URL"), "IDB URL")
+	token := flag.String("token", os.Getenv("IDB_TOKEN"), "IDB API token")
+	insecure := flag.Bool("insecure", false, "skip TLS certificate verification")
+	timezone := flag.String("timezone", os.Getenv("IDB_TIMEZONE"), "time zone of the IDB, e.g. Europe/Berlin (default UTC)")
+	rfc3339 := flag.Bool("rfc3339", envBool("IDB_RFC3339"), "the IDB uses times in RFC 3339 format")
+	listen := flag.String("listen", ":9505", "listen address")
+	interval := flag.Duration("interval", 5*time.Minute, "interval between listings of the machines")
+	maxSeries := flag.Int("max-series", 0, "maximum number of series per metric, 0 for no limit")
+	flag.Parse()
+
+	if *apiURL == "" {
+		log.Fatal("no IDB URL configured")
+	}
+	if *interval <= 0 {
+		log.Fatalf("invalid interval %v, must be positive", *interval)
+	}
+
+	idb, err := idbclient.NewIdb(*apiURL, *token, *insecure)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *timezone != "" {
+		idb.WireTime.Location, err = time.LoadLocation(*timezone)
+		if err != nil {
+			log.Fatalf("timezone: %v", err)
+		}
+	}
+	if *rfc3339 {
+		idb.WireTime.Format = machine.TimeFormatRFC3339
+	}
+
+	e := exporter.New(idb, *interval)
+	e.MaxSeries = *maxSeries
+	go e.Run(nil)
+
+	http.Handle("/metrics", e)
+	log.Fatal(http.ListenAndServe(*listen, nil))
+}
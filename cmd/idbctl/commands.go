@@ -0,0 +1,584 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/idb-project/idbclient"
+	"github.com/idb-project/idbclient/ansible"
+	"github.com/idb-project/idbclient/backup/backuppc"
+	"github.com/idb-project/idbclient/backup/bacula"
+	"github.com/idb-project/idbclient/backup/filebackup"
+	"github.com/idb-project/idbclient/csvimport"
+	"github.com/idb-project/idbclient/machine"
+	"github.com/idb-project/idbclient/reconcile"
+	"github.com/idb-project/idbclient/render"
+	"github.com/idb-project/idbclient/spool"
+)
+
+// newFlagSet returns a flag set for a command which doesn't exit on errors.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	return fs
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	return nil
+}
+
+// outputFlags adds the flags selecting the output format and columns to fs.
+func outputFlags(fs *flag.FlagSet) (format, columns *string) {
+	format = fs.String("o", "json", "output format: json, table, csv, yaml or jsonl")
+	columns = fs.String("c", "", "comma separated columns for table, csv, yaml and jsonl output")
+	return format, columns
+}
+
+// writeMachines writes machines in the format selected by outputFlags.
+func writeMachines(machines []machine.Machine, format, columns string) error {
+	if format == "json" {
+		return printJSON(machines)
+	}
+
+	f, err := render.ParseFormat(format)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	var c []string
+	if columns != "" {
+		c = strings.Split(columns, ",")
+	}
+
+	err = render.CheckColumns(c)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	return render.Write(os.Stdout, f, machines, c)
+}
+
+func cmdGet(idb *idbclient.Idb, args []string) error {
+	fs := newFlagSet("get")
+	format, columns := outputFlags(fs)
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return usageErrorf("no fqdn given")
+	}
+
+	var machines []machine.Machine
+	for _, fqdn := range fs.Args() {
+		m, err := idb.GetMachine(fqdn)
+		if err != nil {
+			return err
+		}
+		machines = append(machines, *m)
+	}
+
+	if *format == "json" && len(machines) == 1 {
+		return printJSON(machines[0])
+	}
+
+	return writeMachines(machines, *format, *columns)
+}
+
+func cmdList(idb *idbclient.Idb, args []string) error {
+	fs := newFlagSet("list")
+	quiet := fs.Bool("q", false, "only print FQDNs")
+	format, columns := outputFlags(fs)
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	machines, err := idb.ListMachines()
+	if err != nil {
+		return err
+	}
+
+	if !*quiet {
+		return writeMachines(machines, *format, *columns)
+	}
+
+	for _, m := range machines {
+		fmt.Println(m.Fqdn)
+	}
+
+	return nil
+}
+
+func cmdUpdate(idb *idbclient.Idb, args []string) error {
+	var sets stringsFlag
+
+	fs := newFlagSet("update")
+	create := fs.Bool("create", false, "create machines not existing yet")
+	file := fs.String("f", "", "JSON or YAML file containing the machine(s), - for stdin")
+	fs.Var(&sets, "set", "set field=value, can be given multiple times")
+	dryRun := fs.Bool("n", false, "only report the changes")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var machines []machine.Machine
+	switch {
+	case *file != "" && fs.NArg() == 0:
+		machines, err = reconcile.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+	case *file == "" && fs.NArg() == 1:
+		machines = []machine.Machine{{Fqdn: fs.Arg(0)}}
+	default:
+		return usageErrorf("either -f or a single fqdn is required")
+	}
+
+	for i := range machines {
+		err = setFields(&machines[i], sets)
+		if err != nil {
+			return err
+		}
+
+		if *dryRun {
+			r, err := idb.PlanUpdate(&machines[i], *create)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(r)
+			continue
+		}
+
+		m, err := idb.UpdateMachine(&machines[i], *create)
+		if err != nil {
+			return err
+		}
+
+		err = printJSON(m)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func cmdDelete(idb *idbclient.Idb, args []string) error {
+	fs := newFlagSet("delete")
+	dryRun := fs.Bool("n", false, "only report the machines which would be deleted")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return usageErrorf("no fqdn given")
+	}
+
+	for _, fqdn := range fs.Args() {
+		if *dryRun {
+			r, err := idb.PlanDelete(fqdn)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(r)
+			continue
+		}
+
+		err := idb.DeleteMachine(fqdn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func cmdDiff(idb *idbclient.Idb, args []string) error {
+	fs := newFlagSet("diff")
+	file := fs.String("f", "", "JSON or YAML file containing the desired machine(s), - for stdin")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if *file == "" || fs.NArg() != 0 {
+		return usageErrorf("-f is required")
+	}
+
+	desired, err := reconcile.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+
+	differences := false
+	for i := range desired {
+		current, err := idb.GetMachine(desired[i].Fqdn)
+		if es, ok := err.(*idbclient.ErrStatus); ok && es.Status() == http.StatusNotFound {
+			fmt.Printf("%v: not found\n", desired[i].Fqdn)
+			current = &machine.Machine{Fqdn: desired[i].Fqdn}
+		} else if err != nil {
+			return err
+		}
+
+		changes, err := machine.Diff(current, &desired[i])
+		if err != nil {
+			return err
+		}
+
+		for _, c := range changes {
+			fmt.Printf("%v: %v: %v -> %v\n", desired[i].Fqdn, c.Field, c.Old, c.New)
+		}
+
+		differences = differences || len(changes) > 0
+	}
+
+	if differences {
+		return errDiff
+	}
+
+	return nil
+}
+
+func cmdApply(idb *idbclient.Idb, args []string) error {
+	fs := newFlagSet("apply")
+	prune := fs.Bool("prune", false, "soft-delete machines which are not in the desired set")
+	dryRun := fs.Bool("n", false, "only print the plan")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageErrorf("a single directory is required")
+	}
+
+	desired, err := reconcile.ReadDir(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	plan, err := reconcile.MakePlan(idb, desired, *prune)
+	if err != nil {
+		return err
+	}
+
+	for _, step := range plan.Steps {
+		if !step.Noop() {
+			fmt.Println(step)
+		}
+	}
+
+	if *dryRun {
+		fmt.Println("Plan:", plan.Summary())
+		return nil
+	}
+
+	summary, errs := plan.Apply(idb)
+	for _, e := range errs {
+		fmt.Fprintln(os.Stderr, e)
+	}
+	fmt.Println("Applied:", summary)
+
+	if len(errs) > 0 {
+		return errs[0].Err
+	}
+
+	return nil
+}
+
+func cmdInventory(idb *idbclient.Idb, args []string) error {
+	fs := newFlagSet("inventory")
+	fs.Bool("list", true, "print the inventory of all machines")
+	host := fs.String("host", "", "print the variables of a single machine")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var buf []byte
+	if *host != "" {
+		vars := map[string]interface{}{}
+
+		m, err := idb.GetMachine(*host)
+		switch es, ok := err.(*idbclient.ErrStatus); {
+		case ok && es.Status() == http.StatusNotFound:
+			// unknown hosts have no variables, as in ansible.Inventory.Host
+		case err != nil:
+			return err
+		case !m.DeletedAt.IsZero():
+			// deleted machines are not in the inventory, as in ansible.Generate
+		default:
+			vars, err = ansible.HostVars(m)
+			if err != nil {
+				return err
+			}
+		}
+
+		buf, err = json.MarshalIndent(vars, "", "  ")
+		if err != nil {
+			return err
+		}
+	} else {
+		machines, err := idb.ListMachines()
+		if err != nil {
+			return err
+		}
+
+		inv, err := ansible.Generate(machines)
+		if err != nil {
+			return err
+		}
+
+		buf, err = inv.List()
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Printf("%s\n", buf)
+	return err
+}
+
+func cmdImport(idb *idbclient.Idb, args []string) error {
+	var mappings stringsFlag
+
+	fs := newFlagSet("import")
+	fs.Var(&mappings, "map", "map a CSV column to a field, header=field, can be given multiple times")
+	comma := fs.String("comma", ",", "field delimiter")
+	apply := fs.Bool("apply", false, "create the machines instead of only validating them")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageErrorf("a single CSV file is required")
+	}
+
+	imp := new(csvimport.Importer)
+	if len(*comma) != 1 {
+		return usageErrorf("invalid delimiter %q", *comma)
+	}
+	imp.Comma = rune((*comma)[0])
+
+	if len(mappings) > 0 {
+		imp.Mapping = make(map[string]string)
+		for _, m := range mappings {
+			kv := strings.SplitN(m, "=", 2)
+			if len(kv) != 2 {
+				return usageErrorf("invalid mapping %q, expected header=field", m)
+			}
+			imp.Mapping[kv[0]] = kv[1]
+		}
+	}
+
+	f := os.Stdin
+	if fs.Arg(0) != "-" {
+		f, err = os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+	}
+
+	result, err := imp.Read(f)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range result.Errors {
+		fmt.Fprintln(os.Stderr, e)
+	}
+
+	var failed error
+	for _, o := range csvimport.Submit(idb, result.Machines, *apply) {
+		switch {
+		case o.Err != nil:
+			fmt.Fprintf(os.Stderr, "%v: %v\n", o.Fqdn, o.Err)
+			failed = o.Err
+		case *apply:
+			fmt.Printf("%v: created\n", o.Fqdn)
+		default:
+			fmt.Println(o.Report)
+		}
+	}
+
+	if failed != nil {
+		return failed
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("%v invalid rows", len(result.Errors))
+	}
+
+	return nil
+}
+
+var backupTimeLayouts = []string{"2006-01-02 15:04:05", time.RFC3339, "2006-01-02"}
+
+func parseBackupTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	for _, l := range backupTimeLayouts {
+		t, err := time.ParseInLocation(l, value, time.Local)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, usageErrorf("invalid time %q for -%v", value, name)
+}
+
+func parseBackupBrand(name string) (machine.BackupBrand, error) {
+	for b := machine.BackupBrandNone; b < machine.BackupBrandEnd; b++ {
+		if strings.EqualFold(name, strings.TrimPrefix(b.String(), "BackupBrand")) {
+			return b, nil
+		}
+	}
+
+	return machine.BackupBrandNone, usageErrorf("unknown backup brand %q", name)
+}
+
+func cmdBackup(idb *idbclient.Idb, args []string) error {
+	fs := newFlagSet("backup")
+	baculaFile := fs.String("bacula", "", "import a Bacula job listing, - for stdin")
+	baculaClient := fs.String("bacula-client", "", "machine of all jobs of a Bacula listing without client column")
+	backuppcDir := fs.String("backuppc", "", "import the BackupPC pc directory")
+	filesDir := fs.String("files", "", "scan a directory of backup archives")
+	fqdn := fs.String("fqdn", "", "machine to update")
+	brand := fs.String("brand", "", "backup brand (bacula, sep, backuppc, file)")
+	full := fs.String("full", "", "time of the last full backup")
+	inc := fs.String("inc", "", "time of the last incremental backup")
+	diff := fs.String("diff", "", "time of the last differential backup")
+	fullSize := fs.Int64("full-size", 0, "size of the last full backup")
+	incSize := fs.Int64("inc-size", 0, "size of the last incremental backup")
+	diffSize := fs.Int64("diff-size", 0, "size of the last differential backup")
+	dryRun := fs.Bool("n", false, "print the updates instead of sending them")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var machines []*machine.Machine
+	switch {
+	case *baculaFile != "":
+		f := os.Stdin
+		if *baculaFile != "-" {
+			f, err = os.Open(*baculaFile)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+		}
+		var resolve bacula.Resolver
+		if *baculaClient != "" {
+			resolve = bacula.Client(*baculaClient)
+		}
+		machines, err = bacula.Import(f, resolve)
+	case *backuppcDir != "":
+		machines, err = backuppc.Import(*backuppcDir, nil)
+	case *filesDir != "":
+		machines, err = filebackup.NewScanner(*filesDir).Scan()
+	case *fqdn != "":
+		m := new(machine.Machine)
+		machines = []*machine.Machine{m}
+
+		var b machine.BackupBrand
+		b, err = parseBackupBrand(*brand)
+		if err != nil {
+			return err
+		}
+
+		var tFull, tInc, tDiff time.Time
+		if tFull, err = parseBackupTime("full", *full); err != nil {
+			return err
+		}
+		if tInc, err = parseBackupTime("inc", *inc); err != nil {
+			return err
+		}
+		if tDiff, err = parseBackupTime("diff", *diff); err != nil {
+			return err
+		}
+
+		err = m.Backup(*fqdn, b, tFull, tInc, tDiff, *fullSize, *incSize, *diffSize)
+	default:
+		return usageErrorf("one of -bacula, -backuppc, -files or -fqdn is required")
+	}
+	if err != nil {
+		return err
+	}
+
+	// a failed update, e.g. of a machine unknown to the IDB, doesn't stop the others
+	failed := 0
+	for _, m := range machines {
+		if *dryRun {
+			// as UpdateMachine would send it
+			err = printJSON(idb.WireTime.JSON(m))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		_, err = idb.UpdateMachine(m, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v: %v\n", m.Fqdn, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%v of %v updates failed", failed, len(machines))
+	}
+
+	return nil
+}
+
+func cmdFlush(idb *idbclient.Idb, args []string) error {
+	fs := newFlagSet("flush")
+	maxAge := fs.Duration("max-age", spool.DefaultMaxAge, "drop spooled updates older than this, 0 keeps them")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageErrorf("exactly one spool directory is required")
+	}
+
+	s, err := spool.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	s.MaxAge = *maxAge
+
+	r, err := s.Flush(idb)
+	for _, e := range r.Rejected {
+		fmt.Fprintln(os.Stderr, e)
+	}
+	for _, e := range r.Unreadable {
+		fmt.Fprintln(os.Stderr, e)
+	}
+	fmt.Fprintf(os.Stderr, "sent: %v, expired: %v, deferred: %v, rejected: %v, unreadable: %v\n", r.Sent, r.Expired, r.Deferred, len(r.Rejected), len(r.Unreadable))
+
+	if err == nil && len(r.Rejected) > 0 {
+		err = r.Rejected[0].Err
+	}
+	if err == nil && len(r.Unreadable) > 0 {
+		err = r.Unreadable[0].Err
+	}
+	return err
+}
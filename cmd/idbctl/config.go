@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+// config holds the connection settings. The config file contains key = value lines with the keys
+// url, token, insecure, timezone and rfc3339. Empty lines and lines starting with # are ignored.
+type config struct {
+	url      string
+	token    string
+	insecure bool
+
+	// IDB time zone, e.g. "Europe/Berlin", and whether times are sent as RFC 3339.
+	timezone string
+	rfc3339  bool
+}
+
+func defaultConfigFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".idbctl")
+}
+
+// load fills unset values from the environment and the config file at path.
+// A missing config file is not an error.
+func (c *config) load(path string) error {
+	if c.url == "" {
+		c.url = os.Getenv("IDB_URL")
+	}
+	if c.token == "" {
+		c.token = os.Getenv("IDB_TOKEN")
+	}
+	if v := os.Getenv("IDB_INSECURE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("IDB_INSECURE: %v", err)
+		}
+		c.insecure = c.insecure || b
+	}
+	if c.timezone == "" {
+		c.timezone = os.Getenv("IDB_TIMEZONE")
+	}
+	if v := os.Getenv("IDB_RFC3339"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("IDB_RFC3339: %v", err)
+		}
+		c.rfc3339 = c.rfc3339 || b
+	}
+
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("%v:%v: expected key = value", path, n)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+
+		switch key {
+		case "url":
+			if c.url == "" {
+				c.url = value
+			}
+		case "token":
+			if c.token == "" {
+				c.token = value
+			}
+		case "insecure":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%v:%v: %v", path, n, err)
+			}
+			c.insecure = c.insecure || b
+		case "timezone":
+			if c.timezone == "" {
+				c.timezone = value
+			}
+		case "rfc3339":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%v:%v: %v", path, n, err)
+			}
+			c.rfc3339 = c.rfc3339 || b
+		default:
+			return fmt.Errorf("%v:%v: unknown key %q", path, n, key)
+		}
+	}
+
+	return s.Err()
+}
+
+// wireTime returns the machine time handling configured by the timezone and rfc3339 settings.
+func (c *config) wireTime() (machine.WireTime, error) {
+	loc := time.UTC
+	if c.timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(c.timezone)
+		if err != nil {
+			return machine.WireTime{}, fmt.Errorf("timezone: %v", err)
+		}
+	}
+
+	format := machine.TimeFormatLegacy
+	if c.rfc3339 {
+		format = machine.TimeFormatRFC3339
+	}
+
+	return machine.WireTime{Location: loc, Format: format}, nil
+}
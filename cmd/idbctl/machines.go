@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+// stringsFlag is a flag which can be given multiple times.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ", ")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// setFields applies field=value assignments, using the JSON field names of machine.Machine.
+func setFields(m *machine.Machine, assignments []string) error {
+	for _, a := range assignments {
+		kv := strings.SplitN(a, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return usageErrorf("invalid assignment %q, expected field=value", a)
+		}
+
+		err := machine.Set(m, kv[0], kv[1])
+		if _, ok := err.(*machine.ErrUnknownField); ok {
+			return usageErrorf("%v", err)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	buf, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Printf("%s\n", buf)
+	return err
+}
@@ -0,0 +1,184 @@
+// Command idbctl queries and modifies machines in the IDB.
+//
+// Usage:
+//
+//	idbctl [global flags] command [flags] [arguments]
+//
+// The IDB URL and API token are read from the flags -url and -token, the environment
+// variables IDB_URL and IDB_TOKEN or the config file (default ~/.idbctl), in this order.
+// The time zone of the IDB is configured the same way with -timezone, IDB_TIMEZONE or the key timezone.
+//
+// Exit codes:
+//
+//	0 success
+//	1 general error
+//	2 usage error
+//	3 machine not found
+//	4 authentication failed
+//	5 IDB unavailable or server error
+//	6 differences found (diff)
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/idb-project/idbclient"
+)
+
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitNotFound
+	exitAuth
+	exitUnavailable
+	exitDiff
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(idb *idbclient.Idb, args []string) error
+}
+
+var commands = []command{
+	{"get", "get [-o format] [-c columns] fqdn...", cmdGet},
+	{"list", "list [-q] [-o format] [-c columns]", cmdList},
+	{"update", "update [-n] [-create] [-f file] [-set field=value]... [fqdn]", cmdUpdate},
+	{"delete", "delete [-n] fqdn...", cmdDelete},
+	{"diff", "diff -f file", cmdDiff},
+	{"apply", "apply [-n] [-prune] dir", cmdApply},
+	{"inventory", "inventory [--list | --host fqdn]", cmdInventory},
+	{"import", "import [-apply] [-comma c] [-map header=field]... file.csv", cmdImport},
+	{"backup", "backup [-bacula file [-bacula-client fqdn] | -backuppc dir | -files dir | -fqdn fqdn -brand brand -full time ...]", cmdBackup},
+	{"flush", "flush [-max-age duration] spooldir", cmdFlush},
+}
+
+// errUsage is returned by commands called with invalid arguments.
+type errUsage struct {
+	msg string
+}
+
+func (e *errUsage) Error() string {
+	return e.msg
+}
+
+func usageErrorf(format string, a ...interface{}) error {
+	return &errUsage{fmt.Sprintf(format, a...)}
+}
+
+// errDiff is returned by the diff command if differences were found.
+var errDiff = errors.New("differences found")
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: idbctl [global flags] command [flags] [arguments]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %v\n", c.usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nGlobal flags:\n")
+	flag.PrintDefaults()
+}
+
+// exitCode maps errors to exit codes.
+func exitCode(err error) int {
+	var eu *errUsage
+	var es *idbclient.ErrStatus
+	var ne net.Error
+	var ue *url.Error
+
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &eu):
+		return exitUsage
+	case err == errDiff:
+		return exitDiff
+	case errors.As(err, &es):
+		switch s := es.Status(); {
+		case s == http.StatusNotFound:
+			return exitNotFound
+		case s == http.StatusUnauthorized || s == http.StatusForbidden:
+			return exitAuth
+		case s >= 500:
+			return exitUnavailable
+		}
+	case errors.As(err, &ne), errors.As(err, &ue):
+		return exitUnavailable
+	}
+
+	return exitError
+}
+
+func main() {
+	var cfg config
+
+	configFile := flag.String("config", defaultConfigFile(), "config file")
+	flag.StringVar(&cfg.url, "url", "", "IDB URL")
+	flag.StringVar(&cfg.token, "token", "", "IDB API token")
+	insecure := flag.Bool("insecure", false, "skip TLS certificate verification")
+	flag.StringVar(&cfg.timezone, "timezone", "", "time zone of the IDB, e.g. Europe/Berlin (default UTC)")
+	rfc3339 := flag.Bool("rfc3339", false, "send times in RFC 3339 format")
+	debug := flag.Bool("debug", false, "log requests and responses")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	err := cfg.load(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "idbctl:", err)
+		os.Exit(exitError)
+	}
+	cfg.insecure = cfg.insecure || *insecure
+	cfg.rfc3339 = cfg.rfc3339 || *rfc3339
+
+	wireTime, err := cfg.wireTime()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "idbctl:", err)
+		os.Exit(exitUsage)
+	}
+
+	if cfg.url == "" {
+		fmt.Fprintln(os.Stderr, "idbctl: no IDB URL configured")
+		os.Exit(exitUsage)
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == flag.Arg(0) {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "idbctl: unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	idb, err := idbclient.NewIdb(cfg.url, cfg.token, cfg.insecure)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "idbctl:", err)
+		os.Exit(exitUsage)
+	}
+	idb.Debug = *debug
+	idb.WireTime = wireTime
+
+	err = cmd.run(idb, flag.Args()[1:])
+	if err != nil && err != errDiff {
+		fmt.Fprintf(os.Stderr, "idbctl %v: %v\n", cmd.name, err)
+		if _, ok := err.(*errUsage); ok {
+			fmt.Fprintf(os.Stderr, "Usage: idbctl %v\n", cmd.usage)
+		}
+	}
+
+	os.Exit(exitCode(err))
+}
@@ -0,0 +1,280 @@
+// Package csvimport reads machines from CSV files, e.g. vendor spreadsheets of new hardware,
+// and submits them to the IDB.
+//
+// Columns are mapped to the JSON field names of machine.Machine, e.g. "serialnumber" or "cores".
+// The enum fields device_type_id, backup_type and backup_brand accept names like "virtual".
+// Network interfaces are set with columns named nic.N.FIELD, where N is the index of the interface
+// starting at 0 and FIELD is one of name, addr, netmask, addr_v6 or netmask_v6.
+package csvimport
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/idb-project/idbclient"
+	"github.com/idb-project/idbclient/machine"
+)
+
+// Importer maps CSV columns to machine fields.
+type Importer struct {
+	// Mapping maps CSV header names to machine fields. Columns not in the mapping are ignored.
+	// If Mapping is nil, the header names are used as field names and unknown columns are an error.
+	Mapping map[string]string
+
+	// Field delimiter, ',' if zero.
+	Comma rune
+}
+
+// RowError describes an invalid row. Row is the line number the row starts at, counting the header as
+// line 1. Lines of quoted cells containing newlines are counted.
+type RowError struct {
+	Row    int
+	Column string
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("row %v: %v", e.Row, e.Err)
+	}
+	return fmt.Sprintf("row %v, column %v: %v", e.Row, e.Column, e.Err)
+}
+
+// ErrHeader is returned if the header can't be mapped to machine fields.
+type ErrHeader struct {
+	reason string
+}
+
+func (e *ErrHeader) Error() string {
+	return "csvimport: " + e.reason
+}
+
+// Result contains the machines of all valid rows and the errors of all invalid rows.
+type Result struct {
+	Machines []machine.Machine
+	Errors   []*RowError
+}
+
+// column is a CSV column mapped to a machine field or interface field.
+type column struct {
+	header string
+	field  string
+	nic    int
+	nicKey string
+}
+
+func (imp *Importer) columns(header []string) ([]*column, error) {
+	columns := make([]*column, len(header))
+	fqdn := false
+
+	for i, h := range header {
+		h = strings.TrimSpace(h)
+
+		field := h
+		if imp.Mapping != nil {
+			var ok bool
+			field, ok = imp.Mapping[h]
+			if !ok {
+				continue
+			}
+		}
+
+		c := &column{header: h, field: field, nic: -1}
+
+		if strings.HasPrefix(field, "nic.") {
+			parts := strings.Split(field, ".")
+			if len(parts) != 3 {
+				return nil, &ErrHeader{fmt.Sprintf("invalid interface column %q", field)}
+			}
+
+			n, err := strconv.Atoi(parts[1])
+			if err != nil || n < 0 {
+				return nil, &ErrHeader{fmt.Sprintf("invalid interface index in %q", field)}
+			}
+
+			switch parts[2] {
+			case "name", "addr", "netmask", "addr_v6", "netmask_v6":
+			default:
+				return nil, &ErrHeader{fmt.Sprintf("unknown interface field in %q", field)}
+			}
+
+			c.nic, c.nicKey = n, parts[2]
+		} else {
+			_, err := machine.LookupField(field)
+			if err != nil {
+				return nil, &ErrHeader{err.Error()}
+			}
+
+			if field == "nics" {
+				return nil, &ErrHeader{fmt.Sprintf("field %q can't be imported", field)}
+			}
+		}
+
+		fqdn = fqdn || field == "fqdn"
+		columns[i] = c
+	}
+
+	if !fqdn {
+		return nil, &ErrHeader{"no column is mapped to fqdn"}
+	}
+
+	return columns, nil
+}
+
+func setNic(m *machine.Machine, c *column, value string) error {
+	for len(m.Nics) <= c.nic {
+		m.Nics = append(m.Nics, machine.Nic{})
+	}
+	nic := &m.Nics[c.nic]
+
+	switch c.nicKey {
+	case "name":
+		nic.Name = value
+	case "addr":
+		if ip := net.ParseIP(value); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("invalid IPv4 address %q", value)
+		}
+		nic.IPAddress.Addr = value
+	case "netmask":
+		if ip := net.ParseIP(value); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("invalid IPv4 netmask %q", value)
+		}
+		nic.IPAddress.Netmask = value
+	case "addr_v6":
+		if ip := net.ParseIP(value); ip == nil || ip.To4() != nil {
+			return fmt.Errorf("invalid IPv6 address %q", value)
+		}
+		nic.IPAddress.AddrV6 = value
+	case "netmask_v6":
+		nic.IPAddress.NetmaskV6 = value
+	}
+
+	return nil
+}
+
+// row converts a CSV record to a machine. Empty cells are skipped.
+func (imp *Importer) row(columns []*column, record []string) (*machine.Machine, *RowError) {
+	m := new(machine.Machine)
+
+	for i, c := range columns {
+		if c == nil || i >= len(record) {
+			continue
+		}
+
+		value := strings.TrimSpace(record[i])
+		if value == "" {
+			continue
+		}
+
+		var err error
+		if c.nic >= 0 {
+			err = setNic(m, c, value)
+		} else {
+			err = machine.Set(m, c.field, value)
+		}
+		if err != nil {
+			return nil, &RowError{Column: c.header, Err: err}
+		}
+	}
+
+	if m.Fqdn == "" {
+		return nil, &RowError{Err: fmt.Errorf("fqdn is empty")}
+	}
+
+	for i, n := range m.Nics {
+		if n.Name == "" {
+			return nil, &RowError{Err: fmt.Errorf("interface %v has no name", i)}
+		}
+	}
+
+	return m, nil
+}
+
+// Read reads machines from r. The first record is the header. Invalid rows are reported in
+// Result.Errors; the returned error is only set if r can't be read as CSV or the header is invalid.
+func (imp *Importer) Read(r io.Reader) (*Result, error) {
+	cr := csv.NewReader(r)
+	if imp.Comma != 0 {
+		cr.Comma = imp.Comma
+	}
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, &ErrHeader{"empty file"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := imp.columns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(Result)
+	seen := make(map[string]int)
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		n, _ := cr.FieldPos(0)
+
+		m, rerr := imp.row(columns, record)
+		if rerr == nil {
+			if first, ok := seen[m.Fqdn]; ok {
+				rerr = &RowError{Err: fmt.Errorf("duplicate fqdn %v, first seen in row %v", m.Fqdn, first)}
+			}
+		}
+		if rerr != nil {
+			rerr.Row = n
+			result.Errors = append(result.Errors, rerr)
+			continue
+		}
+
+		seen[m.Fqdn] = n
+		result.Machines = append(result.Machines, *m)
+	}
+
+	return result, nil
+}
+
+// Outcome is the result of submitting a single machine.
+type Outcome struct {
+	Fqdn string
+
+	// Machine returned by the IDB, nil in dry-run mode or on errors.
+	Machine *machine.Machine
+
+	// Changes the submission would make in dry-run mode, e.g. whether the machine would be created
+	// or an existing one updated. Nil if apply is set or on errors.
+	Report *idbclient.ChangeReport
+
+	Err error
+}
+
+// Submit creates or updates machines in the IDB. If apply is false, nothing is modified and the outcomes
+// report the planned changes, see Idb.PlanUpdate. Errors of single machines don't stop the submission.
+func Submit(idb *idbclient.Idb, machines []machine.Machine, apply bool) []Outcome {
+	outcomes := make([]Outcome, len(machines))
+
+	for i := range machines {
+		outcomes[i].Fqdn = machines[i].Fqdn
+		if apply {
+			outcomes[i].Machine, outcomes[i].Err = idb.UpdateMachine(&machines[i], true)
+		} else {
+			outcomes[i].Report, outcomes[i].Err = idb.PlanUpdate(&machines[i], true)
+		}
+	}
+
+	return outcomes
+}
@@ -0,0 +1,98 @@
+package csvimport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/idb-project/idbclient/idbtest"
+	"github.com/idb-project/idbclient/machine"
+)
+
+var vendorSheet = `Hostname;Serial;CPU cores;RAM (MiB);Type;NIC1;IP1;Mask1;Comment
+a.example.org;SN123;8;16384;physical;eth0;10.0.0.1;255.255.255.0;"rack 4
+shelf 2"
+b.example.org;SN124;eight;16384;physical;eth0;10.0.0.2;255.255.255.0;
+c.example.org;SN125;8;16384;virtual;eth0;10.0.0.300;255.255.255.0;
+;SN126;8;16384;physical;;;;
+a.example.org;SN127;8;16384;physical;;;;
+d.example.org;SN128;4;8192;Switch;;;;
+`
+
+var vendorMapping = map[string]string{
+	"Hostname":  "fqdn",
+	"Serial":    "serialnumber",
+	"CPU cores": "cores",
+	"RAM (MiB)": "ram",
+	"Type":      "device_type_id",
+	"NIC1":      "nic.0.name",
+	"IP1":       "nic.0.addr",
+	"Mask1":     "nic.0.netmask",
+}
+
+func TestRead(t *testing.T) {
+	imp := Importer{Mapping: vendorMapping, Comma: ';'}
+
+	result, err := imp.Read(strings.NewReader(vendorSheet))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Machines) != 2 {
+		t.Fatalf("Got %v machines, expected 2: %+v", len(result.Machines), result.Machines)
+	}
+
+	expected := machine.Machine{Fqdn: "a.example.org", Serialnumber: "SN123", Cores: 8, RAM: 16384, DeviceTypeID: machine.DeviceTypePhyiscal,
+		Nics: []machine.Nic{{Name: "eth0", IPAddress: machine.IPAddress{Addr: "10.0.0.1", Netmask: "255.255.255.0"}}}}
+	if !machine.Equal(&result.Machines[0], &expected) {
+		t.Errorf("Got %+v, expected %+v", result.Machines[0], expected)
+	}
+
+	if result.Machines[1].Fqdn != "d.example.org" || result.Machines[1].DeviceTypeID != machine.DeviceTypeSwitch {
+		t.Errorf("Unexpected machine %+v", result.Machines[1])
+	}
+
+	// the quoted comment of the first row spans two lines
+	rows := []int{4, 5, 6, 7}
+	if len(result.Errors) != len(rows) {
+		t.Fatalf("Got errors %v, expected errors in rows %v", result.Errors, rows)
+	}
+	for i, e := range result.Errors {
+		if e.Row != rows[i] {
+			t.Errorf("Got error %v, expected row %v", e, rows[i])
+		}
+	}
+	if result.Errors[0].Column != "CPU cores" {
+		t.Errorf("Got error %v, expected column CPU cores", result.Errors[0])
+	}
+}
+
+func TestHeader(t *testing.T) {
+	for _, v := range []string{"fqdn,unknown\n", "serialnumber\n", "fqdn,nic.x.addr\n", ""} {
+		_, err := new(Importer).Read(strings.NewReader(v))
+		if _, ok := err.(*ErrHeader); !ok {
+			t.Errorf("%q: got %v, expected ErrHeader", v, err)
+		}
+	}
+}
+
+func TestSubmitDryRun(t *testing.T) {
+	s := idbtest.NewServer("secret")
+	defer s.Close()
+	s.Put(machine.Machine{Fqdn: "a.example.org", Cores: 4})
+
+	outcomes := Submit(s.Idb(), []machine.Machine{{Fqdn: "a.example.org", Cores: 8}, {Fqdn: "b.example.org", Cores: 2}}, false)
+	if len(outcomes) != 2 {
+		t.Fatalf("Got %v outcomes, expected 2", len(outcomes))
+	}
+
+	if r := outcomes[0].Report; outcomes[0].Err != nil || r == nil || r.Create || len(r.Changes) != 1 {
+		t.Errorf("Expected update of a.example.org, got %v %v", r, outcomes[0].Err)
+	}
+	if r := outcomes[1].Report; outcomes[1].Err != nil || r == nil || !r.Create {
+		t.Errorf("Expected creation of b.example.org, got %v %v", r, outcomes[1].Err)
+	}
+
+	if s.Machine("b.example.org") != nil || s.Machine("a.example.org").Cores != 4 {
+		t.Error("Dry run modified the IDB")
+	}
+}
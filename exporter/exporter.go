@@ -0,0 +1,305 @@
+// Package exporter exposes fleet metrics from IDB machine data in the Prometheus text exposition format.
+//
+// The machines are listed periodically by Run or on demand by Refresh; scrapes are served from the last listing.
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+// Lister lists machines, e.g. *idbclient.Idb.
+type Lister interface {
+	ListMachines() ([]machine.Machine, error)
+}
+
+// overflowLabel replaces label values of count metrics exceeding MaxSeries.
+const overflowLabel = "other"
+
+// Exporter caches the machine listing and renders metrics from it.
+type Exporter struct {
+	lister Lister
+
+	// Interval between refreshes by Run, must be positive.
+	Interval time.Duration
+
+	// MaxSeries limits the number of series per metric. Per host metrics exceeding the limit are dropped,
+	// count metrics exceeding it are summed up with the label value "other". Zero means no limit.
+	MaxSeries int
+
+	// Logger receives errors writing metrics to scrapers. If nil, slog.Default() is used.
+	Logger *slog.Logger
+
+	mu           sync.RWMutex
+	machines     []machine.Machine
+	lastRefresh  time.Time
+	lastDuration time.Duration
+	lastErr      error
+	errors       int
+}
+
+// New creates an Exporter listing machines from lister every interval.
+func New(lister Lister, interval time.Duration) *Exporter {
+	return &Exporter{lister: lister, Interval: interval}
+}
+
+// Refresh lists the machines, without deleted ones. On errors, the previous listing is kept.
+func (e *Exporter) Refresh() error {
+	start := time.Now()
+	machines, err := e.lister.ListMachines()
+	duration := time.Since(start)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.lastErr = err
+	e.lastDuration = duration
+	if err != nil {
+		e.errors++
+		return err
+	}
+
+	live := make([]machine.Machine, 0, len(machines))
+	for _, m := range machines {
+		if m.DeletedAt.IsZero() {
+			live = append(live, m)
+		}
+	}
+
+	e.machines = live
+	e.lastRefresh = start
+
+	return nil
+}
+
+// Run refreshes the listing immediately and then every Interval until stop is closed.
+func (e *Exporter) Run(stop <-chan struct{}) {
+	e.Refresh()
+
+	t := time.NewTicker(e.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			e.Refresh()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// ServeHTTP writes the metrics.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	err := e.WriteMetrics(w, time.Now())
+	if err != nil {
+		logger := e.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		logger.Error("writing metrics failed", slog.String("remote", r.RemoteAddr), slog.Any("error", err))
+	}
+}
+
+// series is a single sample of a metric.
+type series struct {
+	labels [][2]string
+	value  float64
+}
+
+// family is a metric with all its series.
+type family struct {
+	name   string
+	help   string
+	typ    string
+	series []series
+}
+
+func (f *family) add(value float64, labels ...string) {
+	s := series{value: value}
+	for i := 0; i+1 < len(labels); i += 2 {
+		s.labels = append(s.labels, [2]string{labels[i], labels[i+1]})
+	}
+	f.series = append(f.series, s)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (f *family) write(w io.Writer) {
+	typ := f.typ
+	if typ == "" {
+		typ = "gauge"
+	}
+	fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n", f.name, f.help, f.name, typ)
+
+	for _, s := range f.series {
+		fmt.Fprint(w, f.name)
+		if len(s.labels) > 0 {
+			labels := make([]string, len(s.labels))
+			for i, l := range s.labels {
+				labels[i] = fmt.Sprintf(`%v="%v"`, l[0], labelEscaper.Replace(l[1]))
+			}
+			fmt.Fprintf(w, "{%v}", strings.Join(labels, ","))
+		}
+		fmt.Fprintf(w, " %v\n", strconv.FormatFloat(s.value, 'g', -1, 64))
+	}
+}
+
+// limit drops series exceeding max and returns the number of dropped series.
+func (f *family) limit(max int) int {
+	if max <= 0 || len(f.series) <= max {
+		return 0
+	}
+
+	dropped := len(f.series) - max
+	f.series = f.series[:max]
+	return dropped
+}
+
+// counts builds a count metric from values. Values exceeding max series, ordered by count, are summed up as "other".
+func counts(name, help, label string, values []string, max int) *family {
+	n := make(map[string]int)
+	for _, v := range values {
+		n[v]++
+	}
+
+	keys := make([]string, 0, len(n))
+	for k := range n {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if n[keys[i]] != n[keys[j]] {
+			return n[keys[i]] > n[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	if max > 0 && len(keys) > max {
+		other := 0
+		for _, k := range keys[max-1:] {
+			other += n[k]
+		}
+		keys = append(keys[:max-1], overflowLabel)
+		n[overflowLabel] = other
+	}
+
+	sort.Strings(keys)
+
+	f := &family{name: name, help: help}
+	for _, k := range keys {
+		f.add(float64(n[k]), label, k)
+	}
+
+	return f
+}
+
+// families builds all metrics from machines at time now.
+func (e *Exporter) families(machines []machine.Machine, now time.Time) []*family {
+	pending := &family{name: "idb_machine_pending_updates", help: "Number of pending updates."}
+	security := &family{name: "idb_machine_pending_security_updates", help: "Number of pending security updates."}
+	uptime := &family{name: "idb_machine_uptime_seconds", help: "Machine uptime."}
+	backupAge := &family{name: "idb_machine_backup_age_seconds", help: "Age of the last backup by kind."}
+
+	var oses, deviceTypes, brands []string
+
+	sorted := make([]*machine.Machine, len(machines))
+	for i := range machines {
+		sorted[i] = &machines[i]
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Fqdn < sorted[j].Fqdn })
+
+	for _, m := range sorted {
+		pending.add(float64(m.PendingUpdates), "fqdn", m.Fqdn)
+		security.add(float64(m.PendingSecurityUpdates), "fqdn", m.Fqdn)
+		uptime.add(float64(m.Uptime), "fqdn", m.Fqdn)
+
+		for _, b := range []struct {
+			kind string
+			t    time.Time
+		}{
+			{"full", m.BackupLastFullRun},
+			{"incremental", m.BackupLastIncRun},
+			{"differential", m.BackupLastDiffRun},
+		} {
+			if !b.t.IsZero() {
+				backupAge.add(now.Sub(b.t).Seconds(), "fqdn", m.Fqdn, "kind", b.kind)
+			}
+		}
+
+		oses = append(oses, m.Os)
+		deviceTypes = append(deviceTypes, m.DeviceTypeID.Name())
+		brands = append(brands, strings.ToLower(strings.TrimPrefix(m.BackupBrand.String(), "BackupBrand")))
+	}
+
+	dropped := &family{name: "idb_exporter_dropped_series", help: "Number of series dropped due to the series limit."}
+	for _, f := range []*family{pending, security, uptime, backupAge} {
+		if n := f.limit(e.MaxSeries); n > 0 {
+			dropped.add(float64(n), "metric", f.name)
+		}
+	}
+
+	return []*family{
+		pending,
+		security,
+		uptime,
+		backupAge,
+		counts("idb_machines_by_os", "Number of machines by operating system.", "os", oses, e.MaxSeries),
+		counts("idb_machines_by_device_type", "Number of machines by device type.", "device_type", deviceTypes, e.MaxSeries),
+		counts("idb_machines_by_backup_brand", "Number of machines by backup brand.", "backup_brand", brands, e.MaxSeries),
+		dropped,
+	}
+}
+
+// WriteMetrics writes all metrics in the Prometheus text exposition format.
+func (e *Exporter) WriteMetrics(w io.Writer, now time.Time) error {
+	e.mu.RLock()
+	machines := e.machines
+	lastRefresh := e.lastRefresh
+	lastDuration := e.lastDuration
+	up := e.lastErr == nil && !lastRefresh.IsZero()
+	errors := e.errors
+	e.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+
+	for _, f := range e.families(machines, now) {
+		f.write(bw)
+	}
+
+	status := []*family{
+		{name: "idb_exporter_up", help: "Whether the last listing of machines succeeded."},
+		{name: "idb_exporter_last_refresh_timestamp_seconds", help: "Time of the last successful listing."},
+		{name: "idb_exporter_refresh_duration_seconds", help: "Duration of the last listing."},
+		{name: "idb_exporter_refresh_errors_total", help: "Number of failed listings.", typ: "counter"},
+		{name: "idb_machines", help: "Number of machines, without deleted ones."},
+	}
+
+	if up {
+		status[0].add(1)
+	} else {
+		status[0].add(0)
+	}
+	if !lastRefresh.IsZero() {
+		status[1].add(float64(lastRefresh.UnixNano()) / 1e9)
+	}
+	status[2].add(lastDuration.Seconds())
+	status[3].add(float64(errors))
+	status[4].add(float64(len(machines)))
+
+	for _, f := range status {
+		f.write(bw)
+	}
+
+	return bw.Flush()
+}
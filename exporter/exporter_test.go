@@ -0,0 +1,134 @@
+package exporter
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+var now = time.Date(2016, 11, 18, 12, 0, 0, 0, time.UTC)
+
+type testLister struct {
+	machines []machine.Machine
+	err      error
+}
+
+func (l *testLister) ListMachines() ([]machine.Machine, error) {
+	return l.machines, l.err
+}
+
+var testMachines = []machine.Machine{
+	{Fqdn: "b", Os: "Debian", DeviceTypeID: machine.DeviceTypeVirtual, PendingUpdates: 3, PendingSecurityUpdates: 1, Uptime: 3600,
+		BackupBrand: machine.BackupBrandBacula, BackupLastFullRun: now.Add(-time.Hour)},
+	{Fqdn: "a", Os: "Debian", DeviceTypeID: machine.DeviceTypePhyiscal},
+	{Fqdn: "c\"quoted\"", Os: "Ubuntu", DeviceTypeID: machine.DeviceTypeVirtual},
+	{Fqdn: "d", Os: "CentOS", DeviceTypeID: machine.DeviceTypeVirtual},
+}
+
+func metrics(t *testing.T, e *Exporter) string {
+	var buf bytes.Buffer
+	err := e.WriteMetrics(&buf, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestWriteMetrics(t *testing.T) {
+	e := New(&testLister{machines: testMachines}, time.Minute)
+	err := e.Refresh()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := metrics(t, e)
+	for _, line := range []string{
+		"# TYPE idb_machine_pending_updates gauge",
+		`idb_machine_pending_updates{fqdn="b"} 3`,
+		`idb_machine_pending_security_updates{fqdn="b"} 1`,
+		`idb_machine_uptime_seconds{fqdn="c\"quoted\""} 0`,
+		`idb_machine_backup_age_seconds{fqdn="b",kind="full"} 3600`,
+		`idb_machines_by_os{os="Debian"} 2`,
+		`idb_machines_by_device_type{device_type="virtual"} 3`,
+		`idb_machines_by_backup_brand{backup_brand="none"} 3`,
+		"idb_exporter_up 1",
+		"idb_machines 4",
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("Missing %q in:\n%v", line, out)
+		}
+	}
+}
+
+func TestMaxSeries(t *testing.T) {
+	e := New(&testLister{machines: testMachines}, time.Minute)
+	e.MaxSeries = 2
+	e.Refresh()
+
+	out := metrics(t, e)
+	for _, line := range []string{
+		`idb_machines_by_os{os="Debian"} 2`,
+		`idb_machines_by_os{os="other"} 2`,
+		`idb_exporter_dropped_series{metric="idb_machine_pending_updates"} 2`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("Missing %q in:\n%v", line, out)
+		}
+	}
+	if strings.Contains(out, `idb_machine_pending_updates{fqdn="d"}`) {
+		t.Errorf("Series not dropped:\n%v", out)
+	}
+}
+
+func TestRefreshError(t *testing.T) {
+	l := &testLister{machines: testMachines}
+	e := New(l, time.Minute)
+	e.Refresh()
+
+	l.err = errors.New("unavailable")
+	if e.Refresh() == nil {
+		t.Fatal("Expected error")
+	}
+
+	out := metrics(t, e)
+	if !strings.Contains(out, "idb_exporter_up 0\n") || !strings.Contains(out, "idb_machines 4\n") || !strings.Contains(out, "idb_exporter_refresh_errors_total 1\n") {
+		t.Errorf("Unexpected metrics:\n%v", out)
+	}
+}
+
+func TestDeletedMachines(t *testing.T) {
+	machines := append([]machine.Machine{{Fqdn: "deleted", Os: "Debian", DeletedAt: now.Add(-time.Hour)}}, testMachines...)
+	e := New(&testLister{machines: machines}, time.Minute)
+	e.Refresh()
+
+	out := metrics(t, e)
+	if strings.Contains(out, `fqdn="deleted"`) || !strings.Contains(out, `idb_machines_by_os{os="Debian"} 2`+"\n") || !strings.Contains(out, "idb_machines 4\n") {
+		t.Errorf("Deleted machine exported:\n%v", out)
+	}
+}
+
+type failingWriter struct {
+	httptest.ResponseRecorder
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestServeHTTPError(t *testing.T) {
+	var log bytes.Buffer
+	e := New(&testLister{machines: testMachines}, time.Minute)
+	e.Logger = slog.New(slog.NewTextHandler(&log, nil))
+	e.Refresh()
+
+	e.ServeHTTP(&failingWriter{*httptest.NewRecorder()}, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(log.String(), "connection reset") {
+		t.Errorf("Write error not logged: %q", log.String())
+	}
+}
@@ -0,0 +1,275 @@
+// Package fixture records IDB traffic to golden files and replays it, for deterministic tests without
+// network access.
+//
+// Record once against a real IDB:
+//
+//	rec := fixture.NewRecorder(idb.Transport)
+//	idb.Transport = rec
+//	... use idb ...
+//	rec.Save("testdata/machines.json")
+//
+// and replay in tests:
+//
+//	rep, err := fixture.Load("testdata/machines.json")
+//	idb.Transport = rep
+//
+// Requests are matched by method, path and normalized query, which has sorted parameters and no
+// idb_api_token. Identical requests are answered with their responses in recorded order. API tokens
+// are never written to fixture files.
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets in recorded interactions.
+const Redacted = "REDACTED"
+
+const tokenParameter = "idb_api_token"
+
+// Request is a recorded request.
+type Request struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+	Body   string `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Interaction is a recorded request with its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// File is the format of fixture files.
+type File struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// normalizeQuery returns the query without the API token, with sorted parameters.
+func normalizeQuery(rawQuery string) string {
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	query.Del(tokenParameter)
+	return query.Encode()
+}
+
+func key(method, path, query string) string {
+	return method + " " + path + "?" + query
+}
+
+// ErrNoInteraction is returned by Replayer for requests without a remaining recorded interaction.
+type ErrNoInteraction struct {
+	method string
+	path   string
+	query  string
+}
+
+func (e *ErrNoInteraction) Error() string {
+	return fmt.Sprintf("fixture: no recorded interaction for %v %v?%v", e.method, e.path, e.query)
+}
+
+// Recorder is a http.RoundTripper which records all interactions. It is safe for concurrent use.
+type Recorder struct {
+	transport http.RoundTripper
+
+	// Secrets are replaced by Redacted in recorded bodies. The API token of each request is always
+	// replaced, and the token parameter is never recorded.
+	Secrets []string
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// NewRecorder creates a Recorder sending requests with transport, http.DefaultTransport if nil.
+func NewRecorder(transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{transport: transport}
+}
+
+func (r *Recorder) scrub(s string, token string) string {
+	if token != "" {
+		s = strings.ReplaceAll(s, token, Redacted)
+	}
+	for _, secret := range r.Secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, Redacted)
+		}
+	}
+	return s
+}
+
+// readBody reads *body completely and replaces it with a reader of the contents.
+func readBody(body *io.ReadCloser) (string, error) {
+	if *body == nil || *body == http.NoBody {
+		return "", nil
+	}
+
+	buf, err := io.ReadAll(*body)
+	(*body).Close()
+	*body = io.NopCloser(bytes.NewReader(buf))
+	return string(buf), err
+}
+
+// RoundTrip sends req and records the interaction.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	token := req.URL.Query().Get(tokenParameter)
+
+	header := resp.Header.Clone()
+	header.Del("Date")
+	header.Del("Set-Cookie")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.interactions = append(r.interactions, Interaction{
+		Request: Request{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  normalizeQuery(req.URL.RawQuery),
+			Body:   r.scrub(requestBody, token),
+		},
+		Response: Response{
+			Status: resp.StatusCode,
+			Header: header,
+			Body:   r.scrub(responseBody, token),
+		},
+	})
+
+	return resp, nil
+}
+
+// Interactions returns the interactions recorded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Save writes the recorded interactions to the fixture file path.
+func (r *Recorder) Save(path string) error {
+	buf, err := json.MarshalIndent(File{r.Interactions()}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(buf, '\n'), 0644)
+}
+
+// Replayer is a http.RoundTripper answering requests from recorded interactions without network access.
+// It is safe for concurrent use.
+type Replayer struct {
+	mu        sync.Mutex
+	responses map[string][]Response
+}
+
+// NewReplayer creates a Replayer from interactions.
+func NewReplayer(interactions []Interaction) *Replayer {
+	r := &Replayer{responses: make(map[string][]Response)}
+	for _, i := range interactions {
+		k := key(i.Request.Method, i.Request.Path, normalizeQuery(i.Request.Query))
+		r.responses[k] = append(r.responses[k], i.Response)
+	}
+	return r
+}
+
+// Load creates a Replayer from the fixture file path.
+func Load(path string) (*Replayer, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f File
+	err = json.Unmarshal(buf, &f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+
+	return NewReplayer(f.Interactions), nil
+}
+
+// RoundTrip answers req with the next recorded response of a matching request.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+
+	query := normalizeQuery(req.URL.RawQuery)
+	k := key(req.Method, req.URL.Path, query)
+
+	r.mu.Lock()
+	responses := r.responses[k]
+	if len(responses) == 0 {
+		r.mu.Unlock()
+		return nil, &ErrNoInteraction{req.Method, req.URL.Path, query}
+	}
+	resp := responses[0]
+	r.responses[k] = responses[1:]
+	r.mu.Unlock()
+
+	header := resp.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.Status, http.StatusText(resp.Status)),
+		StatusCode:    resp.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(resp.Body)),
+		ContentLength: int64(len(resp.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Remaining returns the number of recorded responses not replayed yet.
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, responses := range r.responses {
+		n += len(responses)
+	}
+	return n
+}
@@ -0,0 +1,126 @@
+package fixture
+
+import (
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/idb-project/idbclient"
+	"github.com/idb-project/idbclient/idbtest"
+	"github.com/idb-project/idbclient/machine"
+)
+
+var update = flag.Bool("update", false, "record the golden files against the fake IDB")
+
+const golden = "testdata/machines.json"
+
+// session performs the requests recorded in the golden file.
+func session(idb *idbclient.Idb) (*machine.Machine, []machine.Machine, error) {
+	_, err := idb.UpdateMachine(&machine.Machine{Fqdn: "a.example.com", Cores: 2, Os: "Debian"}, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m, err := idb.GetMachine("a.example.com")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	machines, err := idb.ListMachines()
+	return m, machines, err
+}
+
+func record(t *testing.T, path string) {
+	s := idbtest.NewServer("secret-token")
+	defer s.Close()
+	s.Put(machine.Machine{Fqdn: "b.example.com", Os: "secret-token"})
+
+	idb := s.Idb()
+	rec := NewRecorder(idb.Transport)
+	idb.Transport = rec
+
+	if _, _, err := session(idb); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rec.Save(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	record(t, path)
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(buf), "secret-token") {
+		t.Log("token not scrubbed")
+		t.Fail()
+	}
+}
+
+func TestReplay(t *testing.T) {
+	if *update {
+		record(t, golden)
+	}
+
+	rep, err := Load(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no server is listening, all responses come from the golden file
+	idb, err := idbclient.NewIdb("http://127.0.0.1:1", "other-token", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idb.Transport = rep
+
+	m, machines, err := session(idb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Cores != 2 || len(machines) != 2 || machines[1].Os != Redacted {
+		t.Logf("unexpected replay %+v %+v", m, machines)
+		t.Fail()
+	}
+
+	if rep.Remaining() != 0 {
+		t.Log("unused interactions", rep.Remaining())
+		t.Fail()
+	}
+
+	_, err = idb.GetMachine("a.example.com")
+	var ne *ErrNoInteraction
+	if !errors.As(err, &ne) {
+		t.Log("expected missing interaction, got", err)
+		t.Fail()
+	}
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	for _, c := range []struct {
+		query    string
+		expected string
+	}{
+		{"fqdn=a&idb_api_token=x", "fqdn=a"},
+		{"b=2&a=1", "a=1&b=2"},
+		{"idb_api_token=x", ""},
+	} {
+		if q := normalizeQuery(c.query); q != c.expected {
+			t.Logf("normalizeQuery(%q) = %q, expected %q", c.query, q, c.expected)
+			t.Fail()
+		}
+	}
+}
+
+var _ http.RoundTripper = (*Recorder)(nil)
+var _ http.RoundTripper = (*Replayer)(nil)
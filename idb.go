@@ -6,10 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/idb-project/idbclient/machine"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"path"
+	"time"
 )
 
 const idbVersion = 2
@@ -29,12 +31,40 @@ func (s *ErrStatus) Error() string {
 	return fmt.Sprintf("IDB returned status %v, expected %v. Machine: %+v", s.status, s.expected, s.machine)
 }
 
+// Status returns the HTTP status returned by the IDB.
+func (s *ErrStatus) Status() int {
+	return s.status
+}
+
 // Idb contains IDB client functionality.
 type Idb struct {
 	url   	*url.URL
-	transport *http.Transport
 	apiToken	string
+
+	// Transport sends the requests, e.g. to record them. NewIdb sets it to a *http.Transport.
+	Transport http.RoundTripper
+
+	// Debug logs all requests to stderr if Logger is not set.
 	Debug     bool
+
+	// Logger receives a debug level record for every request, containing the method, the URL with
+	// the API token redacted, the status and the duration.
+	Logger *slog.Logger
+
+	// LogBodySize is the number of bytes of request and response bodies included in the log records.
+	// Zero disables logging of bodies.
+	LogBodySize int
+
+	// Observer is notified about every request, if set.
+	Observer Observer
+
+	// Cache caches GetMachine results, if set. Updates and deletions through this Idb refresh
+	// or invalidate the entries.
+	Cache *Cache
+
+	// WireTime is the location and format of machine times sent to and received from the IDB.
+	// The zero value is UTC with the legacy format.
+	WireTime machine.WireTime
 }
 
 // NewIdb creates a new Idb which uses the IDB found at url.
@@ -50,7 +80,7 @@ func NewIdb(apiURL, apiToken string, insecureSkipVerify bool) (*Idb, error) {
 
 	i.url.Path = fmt.Sprintf("/api/v%v", idbVersion)
 
-	i.transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: insecureSkipVerify}}
+	i.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: insecureSkipVerify}}
 	i.apiToken = apiToken
 
 	return i, nil
@@ -70,18 +100,40 @@ func (i *Idb) request(r *http.Request) (*http.Response, error) {
 	query.Add("idb_api_token", i.apiToken)
 	r.URL.RawQuery = query.Encode()
 
-	if i.Debug {
-		log.Printf("Request: %+v\n", r)
+	logger := i.logger()
+
+	var requestBody *bodyCapture
+	if logger != nil && i.LogBodySize > 0 && r.Body != nil {
+		requestBody = &bodyCapture{limit: i.LogBodySize}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(r.Body, requestBody), r.Body}
 	}
-	client := &http.Client{Transport: i.transport}
 
+	client := &http.Client{Transport: i.Transport}
+
+	start := time.Now()
 	response, err := client.Do(r)
-	if err != nil {
-		return nil, err
+	duration := time.Since(start)
+
+	// transport errors contain the URL, which must not leak the API token
+	if e, ok := err.(*url.Error); ok {
+		err = &url.Error{Op: e.Op, URL: redactURL(r.URL), Err: e.Err}
 	}
 
-	if i.Debug {
-		log.Printf("Response: %+v\n", response)
+	if logger != nil {
+		i.logRequest(logger, r, requestBody, response, duration, err)
+	}
+	if i.Observer != nil {
+		info := RequestInfo{Method: r.Method, Path: r.URL.Path, Start: start, Duration: duration, Err: err}
+		if response != nil {
+			info.Status = response.StatusCode
+		}
+		i.Observer.ObserveRequest(info)
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	return response, err
@@ -89,7 +141,7 @@ func (i *Idb) request(r *http.Request) (*http.Response, error) {
 
 func (i *Idb) decodeResponse(v interface{}, r *http.Response) error {
 	dec := json.NewDecoder(r.Body)
-	err := dec.Decode(v)
+	err := dec.Decode(i.WireTime.JSON(v))
 	if err != nil {
 		return err
 	}
@@ -97,6 +149,59 @@ func (i *Idb) decodeResponse(v interface{}, r *http.Response) error {
 	return nil
 }
 
+// getJSON requests u and decodes the response into v.
+func (i *Idb) getJSON(u *url.URL, v interface{}) error {
+	request, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := i.request(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return newErrStatus(response.StatusCode, http.StatusOK, nil)
+	}
+
+	return i.decodeResponse(v, response)
+}
+
+// sendJSON sends v encoded as JSON with method to u and decodes the response into result, if not nil.
+func (i *Idb) sendJSON(method string, u *url.URL, v, result interface{}) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+
+	err := enc.Encode(i.WireTime.JSON(v))
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(method, u.String(), &body)
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := i.request(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return newErrStatus(response.StatusCode, http.StatusOK, nil)
+	}
+
+	if result == nil {
+		return nil
+	}
+	return i.decodeResponse(result, response)
+}
+
 // UpdateMachine submits new values for a machine in IDB.
 func (i *Idb) UpdateMachine(m *machine.Machine, create bool) (*machine.Machine, error) {
 	var body bytes.Buffer
@@ -104,7 +209,7 @@ func (i *Idb) UpdateMachine(m *machine.Machine, create bool) (*machine.Machine,
 
 	m.CreateMachine = create
 
-	err := enc.Encode(m)
+	err := enc.Encode(i.WireTime.JSON(m))
 	if err != nil {
 		return nil, err
 	}
@@ -118,26 +223,50 @@ func (i *Idb) UpdateMachine(m *machine.Machine, create bool) (*machine.Machine,
 
 	response, err := i.request(request)
 	if err != nil {
+		i.invalidate(m.Fqdn)
 		return nil, err
 	}
+	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
+		i.invalidate(m.Fqdn)
 		return nil, newErrStatus(response.StatusCode, http.StatusOK, m)
 	}
 
 	var newMachine machine.Machine
 
-	dec := json.NewDecoder(response.Body)
-	err = dec.Decode(&newMachine)
+	err = i.decodeResponse(&newMachine, response)
 	if err != nil {
+		i.invalidate(m.Fqdn)
 		return nil, err
 	}
 
+	if i.Cache != nil {
+		i.Cache.set(&newMachine)
+	}
+
 	return &newMachine, err
 }
 
+// invalidate removes the cache entry for fqdn, if caching is enabled.
+func (i *Idb) invalidate(fqdn string) {
+	if i.Cache != nil {
+		i.Cache.Invalidate(fqdn)
+	}
+}
+
 // GetMachine retrieves a single machine identified by fqdn.
 func (i *Idb) GetMachine(fqdn string) (*machine.Machine, error) {
+	if i.Cache == nil {
+		return i.getMachine(fqdn)
+	}
+
+	return i.Cache.get(fqdn, func() (*machine.Machine, error) {
+		return i.getMachine(fqdn)
+	})
+}
+
+func (i *Idb) getMachine(fqdn string) (*machine.Machine, error) {
 	fqdn = url.QueryEscape(fqdn)
 	u := i.joinBaseURL("machines")
 
@@ -154,6 +283,7 @@ func (i *Idb) GetMachine(fqdn string) (*machine.Machine, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer response.Body.Close()
 
 	var newMachine machine.Machine
 	newMachine.Fqdn = fqdn
@@ -166,3 +296,75 @@ func (i *Idb) GetMachine(fqdn string) (*machine.Machine, error) {
 
 	return &newMachine, err
 }
+
+// ListMachines retrieves all machines.
+func (i *Idb) ListMachines() ([]machine.Machine, error) {
+	request, err := http.NewRequest("GET", i.joinBaseURL("machines").String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := i.request(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, newErrStatus(response.StatusCode, http.StatusOK, nil)
+	}
+
+	var machines []machine.Machine
+	err = i.decodeResponse(&machines, response)
+
+	return machines, err
+}
+
+// DeleteMachine deletes the machine identified by fqdn.
+func (i *Idb) DeleteMachine(fqdn string) error {
+	u := i.joinBaseURL("machines")
+
+	query := url.Values{}
+	query.Add("fqdn", fqdn)
+	u.RawQuery = query.Encode()
+
+	request, err := http.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := i.request(request)
+	i.invalidate(fqdn)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return newErrStatus(response.StatusCode, http.StatusOK, &machine.Machine{Fqdn: fqdn})
+	}
+
+	return nil
+}
+
+// UndeleteMachine clears deleted_at of the soft-deleted machine fqdn. The updated machine is returned.
+func (i *Idb) UndeleteMachine(fqdn string) (*machine.Machine, error) {
+	// not a machine.Machine, null must be sent to clear the time
+	undelete := map[string]interface{}{
+		"fqdn":       fqdn,
+		"deleted_at": nil,
+	}
+
+	var newMachine machine.Machine
+	err := i.sendJSON("PUT", i.joinBaseURL("machines"), undelete, &newMachine)
+	if err != nil {
+		i.invalidate(fqdn)
+		return nil, err
+	}
+
+	if i.Cache != nil {
+		i.Cache.set(&newMachine)
+	}
+
+	return &newMachine, nil
+}
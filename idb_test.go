@@ -0,0 +1,240 @@
+package idbclient_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/idb-project/idbclient"
+	"github.com/idb-project/idbclient/idbtest"
+	"github.com/idb-project/idbclient/machine"
+)
+
+func status(err error) int {
+	if s, ok := err.(*idbclient.ErrStatus); ok {
+		return s.Status()
+	}
+	return 0
+}
+
+func TestMachines(t *testing.T) {
+	s := idbtest.NewServer("secret")
+	defer s.Close()
+	idb := s.Idb()
+
+	_, err := idb.UpdateMachine(&machine.Machine{Fqdn: "a.example.com", Cores: 2}, false)
+	if status(err) != http.StatusNotFound {
+		t.Log("update of unknown machine without create:", err)
+		t.Fail()
+	}
+
+	m, err := idb.UpdateMachine(&machine.Machine{Fqdn: "a.example.com", Cores: 2, Os: "Debian"}, true)
+	if err != nil || m.Cores != 2 || m.CreatedAt.IsZero() {
+		t.Logf("create failed: %+v %v", m, err)
+		t.Fail()
+	}
+
+	_, err = idb.UpdateMachine(&machine.Machine{Fqdn: "a.example.com", Cores: 4}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = idb.GetMachine("a.example.com")
+	if err != nil || m.Cores != 4 || m.Os != "Debian" {
+		t.Logf("update not merged: %+v %v", m, err)
+		t.Fail()
+	}
+
+	s.Put(machine.Machine{Fqdn: "b.example.com"})
+	machines, err := idb.ListMachines()
+	if err != nil || len(machines) != 2 {
+		t.Log("list failed:", machines, err)
+		t.Fail()
+	}
+
+	err = idb.DeleteMachine("a.example.com")
+	if err != nil || s.Machine("a.example.com") != nil {
+		t.Log("delete failed:", err)
+		t.Fail()
+	}
+
+	_, err = idb.GetMachine("a.example.com")
+	if status(err) != http.StatusNotFound {
+		t.Log("get of deleted machine:", err)
+		t.Fail()
+	}
+}
+
+func TestToken(t *testing.T) {
+	s := idbtest.NewServer("secret")
+	defer s.Close()
+
+	idb, err := idbclient.NewIdb(s.URL, "wrong", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = idb.ListMachines()
+	if status(err) != http.StatusUnauthorized {
+		t.Log("expected 401, got", err)
+		t.Fail()
+	}
+}
+
+func TestFaults(t *testing.T) {
+	s := idbtest.NewServer("secret")
+	defer s.Close()
+	s.Put(machine.Machine{Fqdn: "a.example.com"})
+	idb := s.Idb()
+
+	s.InjectFault(idbtest.Fault{Status: http.StatusServiceUnavailable, Count: 1})
+	_, err := idb.GetMachine("a.example.com")
+	if status(err) != http.StatusServiceUnavailable {
+		t.Log("expected 503, got", err)
+		t.Fail()
+	}
+
+	s.InjectFault(idbtest.Fault{Method: "GET", Malformed: true, Count: 1})
+	_, err = idb.GetMachine("a.example.com")
+	if err == nil || status(err) != 0 {
+		t.Log("expected decoding error, got", err)
+		t.Fail()
+	}
+
+	s.InjectFault(idbtest.Fault{Latency: 20 * time.Millisecond, Count: 1})
+	start := time.Now()
+	_, err = idb.GetMachine("a.example.com")
+	if err != nil || time.Since(start) < 20*time.Millisecond {
+		t.Log("expected delayed response:", err)
+		t.Fail()
+	}
+
+	if s.Requests() != 3 {
+		t.Log("unexpected request count", s.Requests())
+		t.Fail()
+	}
+}
+
+// bodyTracker counts response bodies which were not closed.
+type bodyTracker struct {
+	transport http.RoundTripper
+	open      atomic.Int64
+}
+
+type trackedBody struct {
+	io.ReadCloser
+	once    sync.Once
+	tracker *bodyTracker
+}
+
+func (b *trackedBody) Close() error {
+	b.once.Do(func() { b.tracker.open.Add(-1) })
+	return b.ReadCloser.Close()
+}
+
+func (t *bodyTracker) RoundTrip(r *http.Request) (*http.Response, error) {
+	response, err := t.transport.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	t.open.Add(1)
+	response.Body = &trackedBody{ReadCloser: response.Body, tracker: t}
+	return response, nil
+}
+
+func TestBodiesClosed(t *testing.T) {
+	s := idbtest.NewServer("secret")
+	defer s.Close()
+	s.Put(machine.Machine{Fqdn: "a.example.com"})
+
+	idb := s.Idb()
+	tracker := &bodyTracker{transport: idb.Transport}
+	idb.Transport = tracker
+
+	calls := map[string]func() error{
+		"ListMachines": func() error { _, err := idb.ListMachines(); return err },
+		"GetMachine":   func() error { _, err := idb.GetMachine("a.example.com"); return err },
+		"UpdateMachine": func() error {
+			_, err := idb.UpdateMachine(&machine.Machine{Fqdn: "a.example.com", Cores: 2}, false)
+			return err
+		},
+		"DeleteMachine": func() error { return idb.DeleteMachine("a.example.com") },
+	}
+
+	for name, call := range calls {
+		call()
+		if n := tracker.open.Load(); n != 0 {
+			t.Logf("%v: %v bodies not closed", name, n)
+			t.Fail()
+		}
+
+		s.InjectFault(idbtest.Fault{Status: http.StatusInternalServerError, Count: 1})
+		call()
+		if n := tracker.open.Load(); n != 0 {
+			t.Logf("%v with error status: %v bodies not closed", name, n)
+			t.Fail()
+		}
+		tracker.open.Store(0)
+	}
+}
+
+type bodyRecorder struct {
+	transport http.RoundTripper
+	bodies    []string
+}
+
+func (b *bodyRecorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Body != nil {
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		b.bodies = append(b.bodies, string(buf))
+		r.Body = io.NopCloser(bytes.NewReader(buf))
+	}
+	return b.transport.RoundTrip(r)
+}
+
+func TestWireTime(t *testing.T) {
+	s := idbtest.NewServer("secret")
+	defer s.Close()
+
+	run := time.Date(2017, 7, 1, 10, 0, 0, 500000000, time.UTC)
+
+	legacy := s.Idb()
+	rfc3339 := s.Idb()
+	rfc3339.WireTime = machine.WireTime{Format: machine.TimeFormatRFC3339}
+
+	for _, c := range []struct {
+		idb      *idbclient.Idb
+		expected string
+	}{
+		{legacy, `"backup_last_full_run":"2017-07-01 10:00:00"`},
+		{rfc3339, `"backup_last_full_run":"2017-07-01T10:00:00.5Z"`},
+	} {
+		rec := &bodyRecorder{transport: c.idb.Transport}
+		c.idb.Transport = rec
+
+		_, err := c.idb.UpdateMachine(&machine.Machine{Fqdn: "a.example.com", BackupLastFullRun: run}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rec.bodies) != 1 || !strings.Contains(rec.bodies[0], c.expected) {
+			t.Logf("expected %v in %v", c.expected, rec.bodies)
+			t.Fail()
+		}
+	}
+
+	// the fake IDB stores whole seconds
+	machines, err := rfc3339.ListMachines()
+	if err != nil || len(machines) != 1 || !machines[0].BackupLastFullRun.Equal(run.Truncate(time.Second)) {
+		t.Log("listed:", machines, err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,158 @@
+package idbtest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/idb-project/idbclient"
+	"github.com/idb-project/idbclient/attachment"
+)
+
+type storedAttachment struct {
+	attachment.Attachment
+	data []byte
+}
+
+// Attachment returns the content of the attachment id of the machine fqdn, or nil.
+func (s *Server) Attachment(fqdn string, id int) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, a := s.findAttachment(fqdn, id)
+	if a == nil {
+		return nil
+	}
+	return bytes.Clone(a.data)
+}
+
+func (s *Server) findAttachment(fqdn string, id int) (int, *storedAttachment) {
+	for i, a := range s.attachments[fqdn] {
+		if a.ID == id {
+			return i, a
+		}
+	}
+	return -1, nil
+}
+
+// getAttachment lists the attachments of a machine, or returns the content of the attachment id.
+func (s *Server) getAttachment(r *http.Request) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	fqdn := query.Get("fqdn")
+	if _, ok := s.machines[fqdn]; !ok {
+		return http.StatusNotFound, nil
+	}
+
+	if query.Get("id") == "" {
+		attachments := []attachment.Attachment{}
+		for _, a := range s.attachments[fqdn] {
+			attachments = append(attachments, a.Attachment)
+		}
+		return http.StatusOK, attachments
+	}
+
+	id, err := strconv.Atoi(query.Get("id"))
+	if err != nil {
+		return http.StatusBadRequest, nil
+	}
+
+	_, a := s.findAttachment(fqdn, id)
+	if a == nil {
+		return http.StatusNotFound, nil
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Type", a.ContentType)
+	header.Set(idbclient.ChecksumHeader, a.Checksum)
+	return http.StatusOK, &raw{header, a.data}
+}
+
+// uploadAttachment stores the part "file" of a multipart body. The field "checksum" must follow and
+// match the content, otherwise 422 is returned.
+func (s *Server) uploadAttachment(r *http.Request) (int, interface{}) {
+	fqdn := r.URL.Query().Get("fqdn")
+
+	s.mu.Lock()
+	_, ok := s.machines[fqdn]
+	s.mu.Unlock()
+	if !ok {
+		return http.StatusNotFound, nil
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return http.StatusBadRequest, nil
+	}
+
+	var a *storedAttachment
+	var checksum string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return http.StatusBadRequest, nil
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return http.StatusBadRequest, nil
+		}
+
+		switch part.FormName() {
+		case "file":
+			a = &storedAttachment{
+				Attachment: attachment.Attachment{
+					Name:        part.FileName(),
+					ContentType: part.Header.Get("Content-Type"),
+					Size:        int64(len(data)),
+					Checksum:    attachment.Checksum(data),
+				},
+				data: data,
+			}
+		case "checksum":
+			checksum = string(data)
+		}
+	}
+
+	if a == nil || a.Name == "" {
+		return http.StatusBadRequest, nil
+	}
+	if checksum != a.Checksum {
+		return http.StatusUnprocessableEntity, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextAttachmentID++
+	a.ID = s.nextAttachmentID
+	s.attachments[fqdn] = append(s.attachments[fqdn], a)
+
+	return http.StatusOK, a.Attachment
+}
+
+func (s *Server) deleteAttachment(r *http.Request) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	fqdn := query.Get("fqdn")
+	id, err := strconv.Atoi(query.Get("id"))
+	if err != nil {
+		return http.StatusBadRequest, nil
+	}
+
+	i, a := s.findAttachment(fqdn, id)
+	if a == nil {
+		return http.StatusNotFound, nil
+	}
+
+	s.attachments[fqdn] = append(s.attachments[fqdn][:i], s.attachments[fqdn][i+1:]...)
+	return http.StatusOK, a.Attachment
+}
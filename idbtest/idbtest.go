@@ -0,0 +1,441 @@
+// Package idbtest provides an in-memory fake IDB for tests.
+//
+// The fake implements the machine API used by idbclient: GET /api/v2/machines with and without fqdn
+// or owner_id, PUT /api/v2/machines honoring create_machine, and DELETE /api/v2/machines. Owners are
+// served read-only by GET /api/v2/owners with and without id or name. Inventory items are served by
+// /api/v2/inventories like machines, identified by inventory_number. Locations are served read-only
+// by GET /api/v2/locations; assigning machines or items to unknown locations fails with 422.
+// Attachments of machines are listed, downloaded and deleted at /api/v2/machines/attachments with fqdn
+// and id, and uploaded as multipart POST. Every request must carry the configured idb_api_token,
+// otherwise 401 is returned.
+//
+// Faults can be injected to test error handling:
+//
+//	s := idbtest.NewServer("token")
+//	defer s.Close()
+//	s.InjectFault(idbtest.Fault{Status: http.StatusServiceUnavailable, Count: 1})
+package idbtest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/idb-project/idbclient"
+	"github.com/idb-project/idbclient/inventory"
+	"github.com/idb-project/idbclient/location"
+	"github.com/idb-project/idbclient/machine"
+	"github.com/idb-project/idbclient/owner"
+)
+
+// Path of the machine endpoint.
+const Path = "/api/v2/machines"
+
+// OwnersPath is the path of the owner endpoint.
+const OwnersPath = "/api/v2/owners"
+
+// InventoriesPath is the path of the inventory endpoint.
+const InventoriesPath = "/api/v2/inventories"
+
+// LocationsPath is the path of the location endpoint.
+const LocationsPath = "/api/v2/locations"
+
+// AttachmentsPath is the path of the machine attachment endpoint.
+const AttachmentsPath = "/api/v2/machines/attachments"
+
+// Fault describes a failure of matching requests.
+type Fault struct {
+	// HTTP method of affected requests, empty for all.
+	Method string
+
+	// Status returned instead of handling the request. Zero handles the request normally.
+	Status int
+
+	// Latency added before responding.
+	Latency time.Duration
+
+	// Malformed truncates the JSON response body.
+	Malformed bool
+
+	// Count of affected requests, zero for all requests until ClearFaults.
+	Count int
+}
+
+func (f *Fault) matches(r *http.Request) bool {
+	return f.Method == "" || f.Method == r.Method
+}
+
+// Server is a fake IDB. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	token string
+
+	mu        sync.Mutex
+	machines  map[string]*machine.Machine
+	owners    map[int]*owner.Owner
+	items     map[string]*inventory.Item
+	locations map[int]*location.Location
+
+	attachments      map[string][]*storedAttachment
+	nextAttachmentID int
+
+	faults   []*Fault
+	requests int
+
+	// now is used for created_at and updated_at.
+	now func() time.Time
+}
+
+// NewServer starts a fake IDB accepting token.
+func NewServer(token string) *Server {
+	s := &Server{
+		token:     token,
+		machines:  make(map[string]*machine.Machine),
+		owners:    make(map[int]*owner.Owner),
+		items:     make(map[string]*inventory.Item),
+		locations: make(map[int]*location.Location),
+
+		attachments: make(map[string][]*storedAttachment),
+		now:         time.Now,
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Idb returns a client for s.
+func (s *Server) Idb() *idbclient.Idb {
+	i, err := idbclient.NewIdb(s.URL, s.token, false)
+	if err != nil {
+		panic(err)
+	}
+	return i
+}
+
+// Put stores m, replacing a machine with the same FQDN.
+func (s *Server) Put(machines ...machine.Machine) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range machines {
+		m := machines[i]
+		s.machines[m.Fqdn] = &m
+	}
+}
+
+// Machine returns the stored machine with fqdn, or nil.
+func (s *Server) Machine(fqdn string) *machine.Machine {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.machines[fqdn]
+	if !ok {
+		return nil
+	}
+	c := *m
+	return &c
+}
+
+// PutOwners stores owners, replacing owners with the same ID.
+func (s *Server) PutOwners(owners ...owner.Owner) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range owners {
+		o := owners[i]
+		s.owners[o.ID] = &o
+	}
+}
+
+// Machines returns all stored machines ordered by FQDN.
+func (s *Server) Machines() []machine.Machine {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.list()
+}
+
+func (s *Server) list() []machine.Machine {
+	machines := make([]machine.Machine, 0, len(s.machines))
+	for _, m := range s.machines {
+		machines = append(machines, *m)
+	}
+	sort.Slice(machines, func(i, j int) bool { return machines[i].Fqdn < machines[j].Fqdn })
+	return machines
+}
+
+// InjectFault adds a fault. Faults are applied in the order they were added, one per request.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// Requests returns the number of requests received.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// fault returns the first fault matching r and consumes it.
+func (s *Server) fault(r *http.Request) *Fault {
+	for i, f := range s.faults {
+		if !f.matches(r) {
+			continue
+		}
+
+		if f.Count > 0 {
+			f.Count--
+			if f.Count == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+
+		c := *f
+		return &c
+	}
+
+	return nil
+}
+
+// handler handles a request, returning the status and a value encoded as JSON or a *raw response.
+type handler func(r *http.Request) (int, interface{})
+
+// raw is a response which is not encoded as JSON.
+type raw struct {
+	header http.Header
+	data   []byte
+}
+
+// routes returns the handlers by path and method.
+func (s *Server) routes() map[string]map[string]handler {
+	return map[string]map[string]handler{
+		Path:            {"GET": s.get, "PUT": s.update, "DELETE": s.delete},
+		OwnersPath:      {"GET": s.getOwner},
+		InventoriesPath: {"GET": s.getInventory, "PUT": s.updateInventory, "DELETE": s.deleteInventory},
+		LocationsPath:   {"GET": s.getLocations},
+		AttachmentsPath: {"GET": s.getAttachment, "POST": s.uploadAttachment, "DELETE": s.deleteAttachment},
+	}
+}
+
+// ServeHTTP handles requests to the fake IDB.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	f := s.fault(r)
+	s.mu.Unlock()
+
+	if f != nil && f.Latency > 0 {
+		select {
+		case <-time.After(f.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if r.URL.Query().Get("idb_api_token") != s.token {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	methods, ok := s.routes()[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if f != nil && f.Status != 0 {
+		http.Error(w, http.StatusText(f.Status), f.Status)
+		return
+	}
+
+	var status int
+	var v interface{}
+
+	if handle, ok := methods[r.Method]; ok {
+		status, v = handle(r)
+	} else {
+		status = http.StatusMethodNotAllowed
+	}
+
+	if v == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	var buf []byte
+	if r, ok := v.(*raw); ok {
+		for k, values := range r.header {
+			w.Header()[k] = values
+		}
+		buf = r.data
+	} else {
+		var err error
+		buf, err = json.Marshal(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	if f != nil && f.Malformed {
+		buf = buf[:len(buf)/2]
+	}
+
+	w.WriteHeader(status)
+	w.Write(buf)
+}
+
+func (s *Server) get(r *http.Request) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v := r.URL.Query().Get("owner_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return http.StatusBadRequest, nil
+		}
+
+		machines := []machine.Machine{}
+		for _, m := range s.list() {
+			if m.OwnerID == id {
+				machines = append(machines, m)
+			}
+		}
+		return http.StatusOK, machines
+	}
+
+	fqdn := r.URL.Query().Get("fqdn")
+	if fqdn == "" {
+		return http.StatusOK, s.list()
+	}
+
+	m, ok := s.machines[fqdn]
+	if !ok {
+		return http.StatusNotFound, nil
+	}
+	return http.StatusOK, m
+}
+
+func (s *Server) getOwner(r *http.Request) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	switch {
+	case query.Get("id") != "":
+		id, err := strconv.Atoi(query.Get("id"))
+		if err != nil {
+			return http.StatusBadRequest, nil
+		}
+		if o, ok := s.owners[id]; ok {
+			return http.StatusOK, o
+		}
+	case query.Get("name") != "":
+		for _, o := range s.owners {
+			if o.Name == query.Get("name") {
+				return http.StatusOK, o
+			}
+		}
+	default:
+		owners := make([]owner.Owner, 0, len(s.owners))
+		for _, o := range s.owners {
+			owners = append(owners, *o)
+		}
+		sort.Slice(owners, func(i, j int) bool { return owners[i].ID < owners[j].ID })
+		return http.StatusOK, owners
+	}
+
+	return http.StatusNotFound, nil
+}
+
+// update merges the fields sent with the stored machine. Unknown machines are only created if
+// create_machine is true.
+func (s *Server) update(r *http.Request) (int, interface{}) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return http.StatusBadRequest, nil
+	}
+
+	var sent map[string]json.RawMessage
+	var update machine.Machine
+	if json.Unmarshal(body, &sent) != nil || json.Unmarshal(body, &update) != nil || update.Fqdn == "" {
+		return http.StatusBadRequest, nil
+	}
+	delete(sent, "create_machine")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().UTC().Truncate(time.Second)
+
+	stored, ok := s.machines[update.Fqdn]
+	if !ok {
+		if !update.CreateMachine {
+			return http.StatusNotFound, nil
+		}
+		stored = &machine.Machine{Fqdn: update.Fqdn, CreatedAt: now}
+	}
+
+	current, err := json.Marshal(stored)
+	if err != nil {
+		return http.StatusInternalServerError, nil
+	}
+
+	var fields map[string]json.RawMessage
+	json.Unmarshal(current, &fields)
+	for k, v := range sent {
+		fields[k] = v
+	}
+
+	merged, err := json.Marshal(fields)
+	if err != nil {
+		return http.StatusInternalServerError, nil
+	}
+
+	m := new(machine.Machine)
+	if json.Unmarshal(merged, m) != nil {
+		return http.StatusBadRequest, nil
+	}
+	m.CreateMachine = false
+	m.UpdatedAt = now
+
+	if !s.knownLocation(m.LocationID) {
+		return http.StatusUnprocessableEntity, nil
+	}
+
+	s.machines[m.Fqdn] = m
+	return http.StatusOK, m
+}
+
+func (s *Server) delete(r *http.Request) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fqdn := r.URL.Query().Get("fqdn")
+	m, ok := s.machines[fqdn]
+	if !ok {
+		return http.StatusNotFound, nil
+	}
+
+	delete(s.machines, fqdn)
+	delete(s.attachments, fqdn)
+	return http.StatusOK, m
+}
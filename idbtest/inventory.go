@@ -0,0 +1,141 @@
+package idbtest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/idb-project/idbclient/inventory"
+)
+
+// PutInventories stores items, replacing items with the same inventory number.
+func (s *Server) PutInventories(items ...inventory.Item) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range items {
+		item := items[i]
+		s.items[item.InventoryNumber] = &item
+	}
+}
+
+// Inventory returns the stored inventory item with number, or nil.
+func (s *Server) Inventory(number string) *inventory.Item {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[number]
+	if !ok {
+		return nil
+	}
+	c := *item
+	return &c
+}
+
+// listInventories returns the items matching match ordered by inventory number.
+func (s *Server) listInventories(match func(*inventory.Item) bool) []inventory.Item {
+	items := make([]inventory.Item, 0, len(s.items))
+	for _, item := range s.items {
+		if match(item) {
+			items = append(items, *item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].InventoryNumber < items[j].InventoryNumber })
+	return items
+}
+
+func (s *Server) getInventory(r *http.Request) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	if _, ok := query["machine"]; ok {
+		fqdn := query.Get("machine")
+		return http.StatusOK, s.listInventories(func(item *inventory.Item) bool { return item.Machine == fqdn })
+	}
+
+	number := query.Get("inventory_number")
+	if number == "" {
+		return http.StatusOK, s.listInventories(func(*inventory.Item) bool { return true })
+	}
+
+	item, ok := s.items[number]
+	if !ok {
+		return http.StatusNotFound, nil
+	}
+	return http.StatusOK, item
+}
+
+// updateInventory merges the fields sent with the stored item. Unknown items are only created if
+// create_inventory is true. Linking to an unknown machine or location fails with 422.
+func (s *Server) updateInventory(r *http.Request) (int, interface{}) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return http.StatusBadRequest, nil
+	}
+
+	var sent map[string]json.RawMessage
+	var update inventory.Item
+	if json.Unmarshal(body, &sent) != nil || json.Unmarshal(body, &update) != nil || update.InventoryNumber == "" {
+		return http.StatusBadRequest, nil
+	}
+	delete(sent, "create_inventory")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.items[update.InventoryNumber]
+	if !ok {
+		if !update.CreateInventory {
+			return http.StatusNotFound, nil
+		}
+		stored = &inventory.Item{InventoryNumber: update.InventoryNumber}
+	}
+
+	current, err := json.Marshal(stored)
+	if err != nil {
+		return http.StatusInternalServerError, nil
+	}
+
+	var fields map[string]json.RawMessage
+	json.Unmarshal(current, &fields)
+	for k, v := range sent {
+		fields[k] = v
+	}
+
+	merged, err := json.Marshal(fields)
+	if err != nil {
+		return http.StatusInternalServerError, nil
+	}
+
+	item := new(inventory.Item)
+	if json.Unmarshal(merged, item) != nil {
+		return http.StatusBadRequest, nil
+	}
+	item.CreateInventory = false
+
+	if _, ok := s.machines[item.Machine]; item.Machine != "" && !ok {
+		return http.StatusUnprocessableEntity, nil
+	}
+	if !s.knownLocation(item.LocationID) {
+		return http.StatusUnprocessableEntity, nil
+	}
+
+	s.items[item.InventoryNumber] = item
+	return http.StatusOK, item
+}
+
+func (s *Server) deleteInventory(r *http.Request) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	number := r.URL.Query().Get("inventory_number")
+	item, ok := s.items[number]
+	if !ok {
+		return http.StatusNotFound, nil
+	}
+
+	delete(s.items, number)
+	return http.StatusOK, item
+}
@@ -0,0 +1,37 @@
+package idbtest
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/idb-project/idbclient/location"
+)
+
+// PutLocations stores locations, replacing locations with the same ID.
+func (s *Server) PutLocations(locations ...location.Location) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range locations {
+		l := locations[i]
+		s.locations[l.ID] = &l
+	}
+}
+
+// knownLocation reports whether id is unset or a stored location.
+func (s *Server) knownLocation(id int) bool {
+	_, ok := s.locations[id]
+	return id == 0 || ok
+}
+
+func (s *Server) getLocations(r *http.Request) (int, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	locations := make([]location.Location, 0, len(s.locations))
+	for _, l := range s.locations {
+		locations = append(locations, *l)
+	}
+	sort.Slice(locations, func(i, j int) bool { return locations[i].ID < locations[j].ID })
+	return http.StatusOK, locations
+}
@@ -0,0 +1,76 @@
+package instrument
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/idb-project/idbclient"
+)
+
+var start = time.Date(2016, 11, 18, 12, 0, 0, 0, time.UTC)
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics([]float64{0.1, 1})
+	o := idbclient.Observers(m)
+
+	o.ObserveRequest(idbclient.RequestInfo{Method: "GET", Path: "/api/v2/machines", Status: 200, Duration: 50 * time.Millisecond})
+	o.ObserveRequest(idbclient.RequestInfo{Method: "GET", Path: "/api/v2/machines", Status: 200, Duration: 500 * time.Millisecond, Retries: 2})
+	o.ObserveRequest(idbclient.RequestInfo{Method: "PUT", Path: "/api/v2/machines", Err: errors.New("refused")})
+
+	var buf bytes.Buffer
+	_, err := m.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		`idb_client_request_duration_seconds_bucket{method="GET",path="/api/v2/machines",status="200",le="0.1"} 1`,
+		`idb_client_request_duration_seconds_bucket{method="GET",path="/api/v2/machines",status="200",le="1"} 2`,
+		`idb_client_request_duration_seconds_bucket{method="GET",path="/api/v2/machines",status="200",le="+Inf"} 2`,
+		`idb_client_request_duration_seconds_sum{method="GET",path="/api/v2/machines",status="200"} 0.55`,
+		`idb_client_request_duration_seconds_count{method="PUT",path="/api/v2/machines",status="error"} 1`,
+		`idb_client_request_retries_total{method="GET",path="/api/v2/machines",status="200"} 2`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("Missing %q in:\n%v", line, buf.String())
+		}
+	}
+}
+
+type testSpan struct {
+	name       string
+	start, end time.Time
+	attributes map[string]interface{}
+	err        error
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attributes[key] = value }
+func (s *testSpan) SetError(err error)                         { s.err = err }
+func (s *testSpan) End(end time.Time)                          { s.end = end }
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) StartSpan(name string, start time.Time) Span {
+	s := &testSpan{name: name, start: start, attributes: make(map[string]interface{})}
+	t.spans = append(t.spans, s)
+	return s
+}
+
+func TestTracing(t *testing.T) {
+	tracer := new(testTracer)
+	NewTracing(tracer).ObserveRequest(idbclient.RequestInfo{Method: "GET", Path: "/api/v2/machines", Status: 404, Start: start, Duration: time.Second})
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("Got %v spans, expected 1", len(tracer.spans))
+	}
+
+	s := tracer.spans[0]
+	if s.name != "IDB GET /api/v2/machines" || !s.end.Equal(start.Add(time.Second)) || s.err == nil || s.attributes["http.response.status_code"] != 404 {
+		t.Errorf("Unexpected span %+v", s)
+	}
+}
@@ -0,0 +1,165 @@
+// Package instrument contains idbclient.Observer implementations recording request metrics and tracing spans
+// without depending on a specific metrics or tracing library.
+package instrument
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/idb-project/idbclient"
+)
+
+// DefaultBuckets are the upper bounds of the request duration histogram buckets in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type seriesKey struct {
+	method string
+	path   string
+	status string
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (k seriesKey) labels() string {
+	return fmt.Sprintf(`method="%v",path="%v",status="%v"`, labelEscaper.Replace(k.method), labelEscaper.Replace(k.path), k.status)
+}
+
+type histogram struct {
+	counts  []uint64
+	count   uint64
+	sum     float64
+	retries uint64
+}
+
+// Metrics records a request duration histogram and retry counter per method, path and status,
+// and writes them in the Prometheus text exposition format. Requests failing without response have
+// the status "error".
+type Metrics struct {
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[seriesKey]*histogram
+}
+
+// NewMetrics creates a Metrics with the given histogram buckets, DefaultBuckets if nil.
+func NewMetrics(buckets []float64) *Metrics {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+
+	b := make([]float64, len(buckets))
+	copy(b, buckets)
+	sort.Float64s(b)
+
+	return &Metrics{buckets: b, series: make(map[seriesKey]*histogram)}
+}
+
+// ObserveRequest implements idbclient.Observer.
+func (m *Metrics) ObserveRequest(info idbclient.RequestInfo) {
+	key := seriesKey{info.Method, info.Path, strconv.Itoa(info.Status)}
+	if info.Err != nil {
+		key.status = "error"
+	}
+
+	seconds := info.Duration.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.series[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.series[key] = h
+	}
+
+	for i, b := range m.buckets {
+		if seconds <= b {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+	h.retries += uint64(info.Retries)
+}
+
+// WriteTo writes the metrics to w.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	keys := make([]seriesKey, 0, len(m.series))
+	series := make(map[seriesKey]histogram, len(m.series))
+	for k, h := range m.series {
+		keys = append(keys, k)
+		c := *h
+		c.counts = append([]uint64(nil), h.counts...)
+		series[k] = c
+	}
+	m.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.path != b.path {
+			return a.path < b.path
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	fmt.Fprint(cw, "# HELP idb_client_request_duration_seconds Duration of requests to the IDB.\n")
+	fmt.Fprint(cw, "# TYPE idb_client_request_duration_seconds histogram\n")
+	for _, k := range keys {
+		h := series[k]
+		labels := k.labels()
+
+		for i, b := range m.buckets {
+			fmt.Fprintf(cw, "idb_client_request_duration_seconds_bucket{%v,le=\"%v\"} %v\n", labels, strconv.FormatFloat(b, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(cw, "idb_client_request_duration_seconds_bucket{%v,le=\"+Inf\"} %v\n", labels, h.count)
+		fmt.Fprintf(cw, "idb_client_request_duration_seconds_sum{%v} %v\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(cw, "idb_client_request_duration_seconds_count{%v} %v\n", labels, h.count)
+	}
+
+	fmt.Fprint(cw, "# HELP idb_client_request_retries_total Number of retries of requests to the IDB.\n")
+	fmt.Fprint(cw, "# TYPE idb_client_request_retries_total counter\n")
+	for _, k := range keys {
+		fmt.Fprintf(cw, "idb_client_request_retries_total{%v} %v\n", k.labels(), series[k].retries)
+	}
+
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+
+	return cw.n, cw.w.Flush()
+}
+
+// ServeHTTP writes the metrics as HTTP response.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
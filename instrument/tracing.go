@@ -0,0 +1,53 @@
+package instrument
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/idb-project/idbclient"
+)
+
+// Tracer creates spans. Adapters for tracing libraries implement it, e.g. by starting an OpenTelemetry
+// span with the given start timestamp.
+type Tracer interface {
+	StartSpan(name string, start time.Time) Span
+}
+
+// Span is a single traced operation.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	SetError(err error)
+	End(end time.Time)
+}
+
+// Tracing creates a span for every request. Spans are created after the request finished,
+// using its start time and duration.
+type Tracing struct {
+	tracer Tracer
+}
+
+// NewTracing creates a Tracing using tracer.
+func NewTracing(tracer Tracer) *Tracing {
+	return &Tracing{tracer}
+}
+
+// ObserveRequest implements idbclient.Observer.
+func (t *Tracing) ObserveRequest(info idbclient.RequestInfo) {
+	span := t.tracer.StartSpan("IDB "+info.Method+" "+info.Path, info.Start)
+
+	span.SetAttribute("http.request.method", info.Method)
+	span.SetAttribute("url.path", info.Path)
+	span.SetAttribute("idb.retries", info.Retries)
+
+	switch {
+	case info.Err != nil:
+		span.SetError(info.Err)
+	case info.Status >= 400:
+		span.SetError(fmt.Errorf("IDB returned status %v", info.Status))
+	}
+	if info.Status != 0 {
+		span.SetAttribute("http.response.status_code", info.Status)
+	}
+
+	span.End(info.Start.Add(info.Duration))
+}
@@ -0,0 +1,93 @@
+package idbclient
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/idb-project/idbclient/inventory"
+)
+
+func (i *Idb) inventoryURL(key, value string) *url.URL {
+	u := i.joinBaseURL("inventories")
+
+	query := url.Values{}
+	query.Add(key, value)
+	u.RawQuery = query.Encode()
+
+	return u
+}
+
+// GetInventory retrieves a single inventory item identified by its inventory number.
+func (i *Idb) GetInventory(number string) (*inventory.Item, error) {
+	var item inventory.Item
+	err := i.getJSON(i.inventoryURL("inventory_number", number), &item)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// ListInventories retrieves all inventory items.
+func (i *Idb) ListInventories() ([]inventory.Item, error) {
+	var items []inventory.Item
+	err := i.getJSON(i.joinBaseURL("inventories"), &items)
+	return items, err
+}
+
+// MachineInventories retrieves the inventory items linked to the machine fqdn.
+func (i *Idb) MachineInventories(fqdn string) ([]inventory.Item, error) {
+	var items []inventory.Item
+	err := i.getJSON(i.inventoryURL("machine", fqdn), &items)
+	return items, err
+}
+
+// UpdateInventory submits new values for an inventory item in IDB.
+// If create is true, the item is created if it doesn't exist.
+// The updated item is returned.
+func (i *Idb) UpdateInventory(item *inventory.Item, create bool) (*inventory.Item, error) {
+	item.CreateInventory = create
+
+	var newItem inventory.Item
+	err := i.sendJSON("PUT", i.joinBaseURL("inventories"), item, &newItem)
+	if err != nil {
+		return nil, err
+	}
+	return &newItem, nil
+}
+
+// LinkInventory links the inventory item number to the machine fqdn. An empty fqdn removes the link.
+// The updated item is returned.
+func (i *Idb) LinkInventory(number, fqdn string) (*inventory.Item, error) {
+	// not an inventory.Item, the empty machine must be sent to unlink
+	link := map[string]string{
+		"inventory_number": number,
+		"machine":          fqdn,
+	}
+
+	var newItem inventory.Item
+	err := i.sendJSON("PUT", i.joinBaseURL("inventories"), link, &newItem)
+	if err != nil {
+		return nil, err
+	}
+	return &newItem, nil
+}
+
+// DeleteInventory deletes the inventory item number.
+func (i *Idb) DeleteInventory(number string) error {
+	request, err := http.NewRequest("DELETE", i.inventoryURL("inventory_number", number).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := i.request(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return newErrStatus(response.StatusCode, http.StatusOK, nil)
+	}
+
+	return nil
+}
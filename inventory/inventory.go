@@ -0,0 +1,101 @@
+// Package inventory contains the IDB inventory model. Inventory items are hardware assets like servers,
+// switches or disks, identified by their inventory number and optionally linked to a machine.
+package inventory
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DateLayout is the layout of dates on the wire.
+const DateLayout = "2006-01-02"
+
+// Date is a calendar date, encoded as "2006-01-02". The zero Date is encoded as an empty string.
+type Date struct {
+	time.Time
+}
+
+// NewDate returns the date of year, month and day.
+func NewDate(year int, month time.Month, day int) *Date {
+	return &Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// String returns the date as "2006-01-02", or an empty string if d is nil or zero.
+func (d *Date) String() string {
+	if d == nil || d.IsZero() {
+		return ""
+	}
+	return d.Format(DateLayout)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(buf []byte) error {
+	var s string
+	err := json.Unmarshal(buf, &s)
+	if err != nil {
+		return err
+	}
+
+	if s == "" {
+		d.Time = time.Time{}
+		return nil
+	}
+
+	// full timestamps are accepted, only the date is kept
+	if len(s) > len(DateLayout) {
+		s = s[:len(DateLayout)]
+	}
+
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return err
+	}
+	d.Time = t
+	return nil
+}
+
+// Item represents a IDB inventory item.
+type Item struct {
+	// Inventory number, the unique identifier of the item.
+	InventoryNumber string `json:"inventory_number"`
+
+	// Name and category of the item, e.g. "Storage shelf" and "Server".
+	Name     string `json:"name,omitempty"`
+	Category string `json:"category,omitempty"`
+
+	// Serial and part number of the manufacturer.
+	Serial string `json:"serial,omitempty"`
+	Part   string `json:"part,omitempty"`
+
+	// Seller, purchase date and end of the warranty. Unset dates are nil.
+	Seller       string `json:"seller,omitempty"`
+	PurchaseDate *Date  `json:"purchase_date,omitempty"`
+	WarrantyEnd  *Date  `json:"warranty_end,omitempty"`
+
+	// Status, e.g. "active" or "retired".
+	Status string `json:"status,omitempty"`
+
+	// FQDN of the linked machine, empty if the item is not linked.
+	Machine string `json:"machine,omitempty"`
+
+	// Owner and location IDs.
+	OwnerID    int `json:"owner_id,omitempty"`
+	LocationID int `json:"location_id,omitempty"`
+
+	Comment string `json:"comment,omitempty"`
+
+	// Only used to signal the IDB to create the item.
+	CreateInventory bool `json:"create_inventory,string,omitempty"`
+}
+
+// Warranty reports whether the warranty of i is valid at t, including the day of the warranty end.
+// Items without warranty end are reported as not covered.
+func (i *Item) Warranty(t time.Time) bool {
+	if i.WarrantyEnd == nil || i.WarrantyEnd.IsZero() {
+		return false
+	}
+	return t.Before(i.WarrantyEnd.AddDate(0, 0, 1))
+}
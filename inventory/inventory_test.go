@@ -0,0 +1,80 @@
+package inventory
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+var marshalTests = []struct {
+	i Item
+	j string
+}{
+	{Item{InventoryNumber: "1"}, `{"inventory_number":"1"}`},
+	{
+		Item{InventoryNumber: "2", PurchaseDate: NewDate(2017, 3, 4), Machine: "a.example.com", CreateInventory: true},
+		`{"inventory_number":"2","purchase_date":"2017-03-04","machine":"a.example.com","create_inventory":"true"}`,
+	},
+}
+
+func TestMarshal(t *testing.T) {
+	for _, v := range marshalTests {
+		buf, err := json.Marshal(v.i)
+		if err != nil || string(buf) != v.j {
+			t.Logf("%+v: %s %v, expected %v", v.i, buf, err, v.j)
+			t.Fail()
+		}
+
+		var i Item
+		err = json.Unmarshal([]byte(v.j), &i)
+		if err != nil || i.InventoryNumber != v.i.InventoryNumber || i.CreateInventory != v.i.CreateInventory ||
+			i.PurchaseDate.String() != v.i.PurchaseDate.String() {
+			t.Logf("%v: %+v %v, expected %+v", v.j, i, err, v.i)
+			t.Fail()
+		}
+	}
+}
+
+var dateTests = []struct {
+	j   string
+	d   string
+	err bool
+}{
+	{`""`, "", false},
+	{`"2017-03-04"`, "2017-03-04", false},
+	{`"2017-03-04T05:06:07+02:00"`, "2017-03-04", false},
+	{`"04.03.2017"`, "", true},
+	{`17`, "", true},
+}
+
+func TestDate(t *testing.T) {
+	for _, v := range dateTests {
+		var d Date
+		err := json.Unmarshal([]byte(v.j), &d)
+		if (err != nil) != v.err || (err == nil && d.String() != v.d) {
+			t.Logf("%v: %q %v, expected %q", v.j, d, err, v.d)
+			t.Fail()
+		}
+	}
+}
+
+func TestWarranty(t *testing.T) {
+	i := Item{WarrantyEnd: NewDate(2020, 6, 30)}
+
+	tests := map[time.Time]bool{
+		time.Date(2020, 6, 30, 23, 59, 0, 0, time.UTC): true,
+		time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC):    false,
+		time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC):    true,
+	}
+	for at, expected := range tests {
+		if i.Warranty(at) != expected {
+			t.Logf("warranty at %v: %v, expected %v", at, !expected, expected)
+			t.Fail()
+		}
+	}
+
+	if (&Item{}).Warranty(time.Now()) {
+		t.Log("item without warranty end is covered")
+		t.Fail()
+	}
+}
@@ -0,0 +1,87 @@
+package idbclient_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/idb-project/idbclient/idbtest"
+	"github.com/idb-project/idbclient/inventory"
+	"github.com/idb-project/idbclient/machine"
+)
+
+func TestInventory(t *testing.T) {
+	s := idbtest.NewServer("secret")
+	defer s.Close()
+	s.Put(machine.Machine{Fqdn: "a.example.com"})
+	idb := s.Idb()
+
+	_, err := idb.UpdateInventory(&inventory.Item{InventoryNumber: "1000", Name: "Server"}, false)
+	if status(err) != http.StatusNotFound {
+		t.Log("update of unknown item without create:", err)
+		t.Fail()
+	}
+
+	item, err := idb.UpdateInventory(&inventory.Item{
+		InventoryNumber: "1000",
+		Name:            "Server",
+		Serial:          "S123",
+		PurchaseDate:    inventory.NewDate(2017, 3, 4),
+	}, true)
+	if err != nil || item.Serial != "S123" || item.CreateInventory {
+		t.Log("create failed:", item, err)
+		t.Fail()
+	}
+
+	// omitted fields are kept
+	_, err = idb.UpdateInventory(&inventory.Item{InventoryNumber: "1000", Status: "active"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err = idb.GetInventory("1000")
+	if err != nil || item.Name != "Server" || item.Status != "active" || item.PurchaseDate.String() != "2017-03-04" {
+		t.Log("get after update:", item, err)
+		t.Fail()
+	}
+
+	item, err = idb.LinkInventory("1000", "a.example.com")
+	if err != nil || item.Machine != "a.example.com" {
+		t.Log("link:", item, err)
+		t.Fail()
+	}
+
+	_, err = idb.LinkInventory("1000", "unknown.example.com")
+	if status(err) != http.StatusUnprocessableEntity {
+		t.Log("link to unknown machine:", err)
+		t.Fail()
+	}
+
+	items, err := idb.MachineInventories("a.example.com")
+	if err != nil || len(items) != 1 || items[0].InventoryNumber != "1000" {
+		t.Log("machine inventories:", items, err)
+		t.Fail()
+	}
+
+	item, err = idb.LinkInventory("1000", "")
+	if err != nil || item.Machine != "" || item.Name != "Server" {
+		t.Log("unlink:", item, err)
+		t.Fail()
+	}
+
+	items, err = idb.ListInventories()
+	if err != nil || len(items) != 1 {
+		t.Log("list:", items, err)
+		t.Fail()
+	}
+
+	err = idb.DeleteInventory("1000")
+	if err != nil || s.Inventory("1000") != nil {
+		t.Log("delete:", err)
+		t.Fail()
+	}
+
+	_, err = idb.GetInventory("1000")
+	if status(err) != http.StatusNotFound {
+		t.Log("get after delete:", err)
+		t.Fail()
+	}
+}
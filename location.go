@@ -0,0 +1,80 @@
+package idbclient
+
+import (
+	"github.com/idb-project/idbclient/inventory"
+	"github.com/idb-project/idbclient/location"
+	"github.com/idb-project/idbclient/machine"
+)
+
+// ListLocations retrieves all locations.
+func (i *Idb) ListLocations() ([]location.Location, error) {
+	var locations []location.Location
+	err := i.getJSON(i.joinBaseURL("locations"), &locations)
+	return locations, err
+}
+
+// LocationTree retrieves all locations and returns their hierarchy.
+func (i *Idb) LocationTree() (*location.Tree, error) {
+	locations, err := i.ListLocations()
+	if err != nil {
+		return nil, err
+	}
+	return location.NewTree(locations)
+}
+
+// AssignMachineLocation assigns the machine fqdn to the location id. Zero removes the assignment.
+// The updated machine is returned.
+func (i *Idb) AssignMachineLocation(fqdn string, id int) (*machine.Machine, error) {
+	// not a machine.Machine, zero must be sent to remove the assignment
+	assignment := map[string]interface{}{
+		"fqdn":        fqdn,
+		"location_id": id,
+	}
+
+	var newMachine machine.Machine
+	err := i.sendJSON("PUT", i.joinBaseURL("machines"), assignment, &newMachine)
+	if err != nil {
+		i.invalidate(fqdn)
+		return nil, err
+	}
+
+	if i.Cache != nil {
+		i.Cache.set(&newMachine)
+	}
+
+	return &newMachine, nil
+}
+
+// AssignInventoryLocation assigns the inventory item number to the location id. Zero removes the
+// assignment. The updated item is returned.
+func (i *Idb) AssignInventoryLocation(number string, id int) (*inventory.Item, error) {
+	assignment := map[string]interface{}{
+		"inventory_number": number,
+		"location_id":      id,
+	}
+
+	var newItem inventory.Item
+	err := i.sendJSON("PUT", i.joinBaseURL("inventories"), assignment, &newItem)
+	if err != nil {
+		return nil, err
+	}
+	return &newItem, nil
+}
+
+// MachineLocationPath returns the full location path of the machine fqdn, e.g.
+// "Berlin / Room 1.03 / A12", or an empty string if the machine has no location.
+func (i *Idb) MachineLocationPath(fqdn string) (string, error) {
+	m, err := i.GetMachine(fqdn)
+	if err != nil {
+		return "", err
+	}
+	if m.LocationID == 0 {
+		return "", nil
+	}
+
+	t, err := i.LocationTree()
+	if err != nil {
+		return "", err
+	}
+	return t.MachinePath(m)
+}
@@ -0,0 +1,193 @@
+// Package location contains the IDB location model. Locations form a hierarchy of sites, rooms in
+// sites and racks in rooms. Machines and inventory items reference a location by ID, usually a rack.
+package location
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+// Level is the level of a location in the hierarchy.
+type Level string
+
+const (
+	LevelSite Level = "site"
+	LevelRoom Level = "room"
+	LevelRack Level = "rack"
+)
+
+// parentLevel maps levels to the level of their parent. Sites have no parent.
+var parentLevel = map[Level]Level{
+	LevelSite: "",
+	LevelRoom: LevelSite,
+	LevelRack: LevelRoom,
+}
+
+// Separator separates the location names of a path.
+const Separator = " / "
+
+// Location represents a IDB location entry.
+type Location struct {
+	// ID referenced by machines and inventory items.
+	ID int `json:"id"`
+
+	// Name, e.g. "Berlin", "Room 1.03" or "A12".
+	Name string `json:"name"`
+
+	Level Level `json:"level"`
+
+	// ID of the parent location, zero for sites.
+	ParentID int `json:"parent_id,omitempty"`
+
+	Description string `json:"description,omitempty"`
+}
+
+// ErrHierarchy is returned by NewTree if locations don't form a valid hierarchy.
+type ErrHierarchy struct {
+	location Location
+	reason   string
+}
+
+func (e *ErrHierarchy) Error() string {
+	return fmt.Sprintf("location %v (%v): %v", e.location.ID, e.location.Name, e.reason)
+}
+
+// ErrUnknownLocation is returned if a location ID is not part of a Tree.
+type ErrUnknownLocation struct {
+	id int
+}
+
+func (e *ErrUnknownLocation) Error() string {
+	return fmt.Sprintf("unknown location %v", e.id)
+}
+
+// Node is a location in a Tree.
+type Node struct {
+	Location
+
+	// Parent is nil for sites.
+	Parent *Node
+
+	// Children ordered by name.
+	Children []*Node
+}
+
+// Path returns the locations from the site down to n.
+func (n *Node) Path() []Location {
+	var path []Location
+	for ; n != nil; n = n.Parent {
+		path = append([]Location{n.Location}, path...)
+	}
+	return path
+}
+
+// String returns the names of the path of n, joined by Separator.
+func (n *Node) String() string {
+	var names []string
+	for _, l := range n.Path() {
+		names = append(names, l.Name)
+	}
+	return strings.Join(names, Separator)
+}
+
+// Tree is the location hierarchy.
+type Tree struct {
+	// Sites ordered by name.
+	Sites []*Node
+
+	nodes map[int]*Node
+}
+
+// NewTree builds the hierarchy of locations. Every room must be in a site and every rack in a room.
+func NewTree(locations []Location) (*Tree, error) {
+	t := &Tree{nodes: make(map[int]*Node)}
+
+	for _, l := range locations {
+		if _, ok := parentLevel[l.Level]; !ok {
+			return nil, &ErrHierarchy{l, fmt.Sprintf("unknown level %q", l.Level)}
+		}
+		if _, ok := t.nodes[l.ID]; ok {
+			return nil, &ErrHierarchy{l, "duplicate ID"}
+		}
+		t.nodes[l.ID] = &Node{Location: l}
+	}
+
+	for _, n := range t.nodes {
+		expected := parentLevel[n.Level]
+		if expected == "" {
+			if n.ParentID != 0 {
+				return nil, &ErrHierarchy{n.Location, "site with parent"}
+			}
+			t.Sites = append(t.Sites, n)
+			continue
+		}
+
+		parent, ok := t.nodes[n.ParentID]
+		if !ok {
+			return nil, &ErrHierarchy{n.Location, fmt.Sprintf("unknown parent %v", n.ParentID)}
+		}
+		if parent.Level != expected {
+			return nil, &ErrHierarchy{n.Location, fmt.Sprintf("%v in %v, expected %v", n.Level, parent.Level, expected)}
+		}
+
+		n.Parent = parent
+		parent.Children = append(parent.Children, n)
+	}
+
+	sortNodes(t.Sites)
+	for _, n := range t.nodes {
+		sortNodes(n.Children)
+	}
+
+	return t, nil
+}
+
+func sortNodes(nodes []*Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Name != nodes[j].Name {
+			return nodes[i].Name < nodes[j].Name
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+}
+
+// Node returns the location id.
+func (t *Tree) Node(id int) (*Node, error) {
+	n, ok := t.nodes[id]
+	if !ok {
+		return nil, &ErrUnknownLocation{id}
+	}
+	return n, nil
+}
+
+// Walk calls f for every location, parents before their children.
+func (t *Tree) Walk(f func(n *Node)) {
+	var walk func(nodes []*Node)
+	walk = func(nodes []*Node) {
+		for _, n := range nodes {
+			f(n)
+			walk(n.Children)
+		}
+	}
+	walk(t.Sites)
+}
+
+// Path returns the full path of the location id, e.g. "Berlin / Room 1.03 / A12".
+func (t *Tree) Path(id int) (string, error) {
+	n, err := t.Node(id)
+	if err != nil {
+		return "", err
+	}
+	return n.String(), nil
+}
+
+// MachinePath returns the full location path of m, or an empty string if m has no location.
+func (t *Tree) MachinePath(m *machine.Machine) (string, error) {
+	if m.LocationID == 0 {
+		return "", nil
+	}
+	return t.Path(m.LocationID)
+}
@@ -0,0 +1,79 @@
+package location
+
+import (
+	"testing"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+var testLocations = []Location{
+	{ID: 1, Name: "Berlin", Level: LevelSite},
+	{ID: 2, Name: "Room 2", Level: LevelRoom, ParentID: 1},
+	{ID: 3, Name: "Room 1", Level: LevelRoom, ParentID: 1},
+	{ID: 4, Name: "A12", Level: LevelRack, ParentID: 3},
+	{ID: 5, Name: "Aachen", Level: LevelSite},
+}
+
+func TestTree(t *testing.T) {
+	tree, err := NewTree(testLocations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	tree.Walk(func(n *Node) { names = append(names, n.Name) })
+	expected := []string{"Aachen", "Berlin", "Room 1", "A12", "Room 2"}
+	if len(names) != len(expected) {
+		t.Fatalf("walk: %v, expected %v", names, expected)
+	}
+	for i := range names {
+		if names[i] != expected[i] {
+			t.Logf("walk: %v, expected %v", names, expected)
+			t.Fail()
+			break
+		}
+	}
+
+	path, err := tree.Path(4)
+	if err != nil || path != "Berlin / Room 1 / A12" {
+		t.Logf("path: %q %v", path, err)
+		t.Fail()
+	}
+
+	_, err = tree.Path(9)
+	if _, ok := err.(*ErrUnknownLocation); !ok {
+		t.Log("path of unknown location:", err)
+		t.Fail()
+	}
+
+	path, err = tree.MachinePath(&machine.Machine{Fqdn: "a.example.com", LocationID: 2})
+	if err != nil || path != "Berlin / Room 2" {
+		t.Logf("machine path: %q %v", path, err)
+		t.Fail()
+	}
+
+	path, err = tree.MachinePath(&machine.Machine{Fqdn: "b.example.com"})
+	if err != nil || path != "" {
+		t.Logf("machine path without location: %q %v", path, err)
+		t.Fail()
+	}
+}
+
+var hierarchyTests = [][]Location{
+	{{ID: 1, Name: "Rack", Level: LevelRack}},
+	{{ID: 1, Name: "Site", Level: LevelSite, ParentID: 2}, {ID: 2, Name: "Other", Level: LevelSite}},
+	{{ID: 1, Name: "Site", Level: LevelSite}, {ID: 2, Name: "Rack", Level: LevelRack, ParentID: 1}},
+	{{ID: 1, Name: "Room", Level: LevelRoom, ParentID: 2}},
+	{{ID: 1, Name: "Floor", Level: "floor"}},
+	{{ID: 1, Name: "Site", Level: LevelSite}, {ID: 1, Name: "Other", Level: LevelSite}},
+}
+
+func TestHierarchy(t *testing.T) {
+	for _, v := range hierarchyTests {
+		_, err := NewTree(v)
+		if _, ok := err.(*ErrHierarchy); !ok {
+			t.Logf("%+v: %v, expected ErrHierarchy", v, err)
+			t.Fail()
+		}
+	}
+}
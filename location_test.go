@@ -0,0 +1,76 @@
+package idbclient_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/idb-project/idbclient"
+	"github.com/idb-project/idbclient/idbtest"
+	"github.com/idb-project/idbclient/inventory"
+	"github.com/idb-project/idbclient/location"
+	"github.com/idb-project/idbclient/machine"
+)
+
+func TestLocations(t *testing.T) {
+	s := idbtest.NewServer("secret")
+	defer s.Close()
+	s.PutLocations(
+		location.Location{ID: 1, Name: "Berlin", Level: location.LevelSite},
+		location.Location{ID: 2, Name: "Room 1", Level: location.LevelRoom, ParentID: 1},
+		location.Location{ID: 3, Name: "A12", Level: location.LevelRack, ParentID: 2},
+	)
+	s.Put(machine.Machine{Fqdn: "a.example.com", Cores: 2})
+	s.PutInventories(inventory.Item{InventoryNumber: "1000"})
+
+	idb := s.Idb()
+	idb.Cache = idbclient.NewCache(time.Minute, 0)
+
+	tree, err := idb.LocationTree()
+	if err != nil || len(tree.Sites) != 1 || len(tree.Sites[0].Children) != 1 {
+		t.Fatal("tree:", tree, err)
+	}
+
+	path, err := idb.MachineLocationPath("a.example.com")
+	if err != nil || path != "" {
+		t.Logf("path without location: %q %v", path, err)
+		t.Fail()
+	}
+
+	m, err := idb.AssignMachineLocation("a.example.com", 3)
+	if err != nil || m.LocationID != 3 || m.Cores != 2 {
+		t.Log("assign machine:", m, err)
+		t.Fail()
+	}
+
+	// served from the updated cache
+	path, err = idb.MachineLocationPath("a.example.com")
+	if err != nil || path != "Berlin / Room 1 / A12" {
+		t.Logf("path: %q %v", path, err)
+		t.Fail()
+	}
+
+	_, err = idb.AssignMachineLocation("a.example.com", 9)
+	if status(err) != http.StatusUnprocessableEntity {
+		t.Log("assign machine to unknown location:", err)
+		t.Fail()
+	}
+
+	m, err = idb.AssignMachineLocation("a.example.com", 0)
+	if err != nil || m.LocationID != 0 {
+		t.Log("remove machine assignment:", m, err)
+		t.Fail()
+	}
+
+	item, err := idb.AssignInventoryLocation("1000", 3)
+	if err != nil || item.LocationID != 3 {
+		t.Log("assign item:", item, err)
+		t.Fail()
+	}
+
+	_, err = idb.AssignInventoryLocation("2000", 3)
+	if status(err) != http.StatusNotFound {
+		t.Log("assign unknown item:", err)
+		t.Fail()
+	}
+}
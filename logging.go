@@ -0,0 +1,132 @@
+package idbclient
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+var debugLogger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+// logger returns the logger for requests, nil if requests aren't logged.
+func (i *Idb) logger() *slog.Logger {
+	if i.Logger != nil {
+		return i.Logger
+	}
+	if i.Debug {
+		return debugLogger
+	}
+	return nil
+}
+
+// redactURL returns u as string with the API token replaced.
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	if _, ok := query["idb_api_token"]; !ok {
+		return u.String()
+	}
+
+	query.Set("idb_api_token", redacted)
+
+	r := *u
+	r.RawQuery = query.Encode()
+	return r.String()
+}
+
+// bodyCapture keeps the first limit bytes written to it. It is safe for concurrent use, as the
+// transport may still be sending a streamed request body while the response is logged.
+type bodyCapture struct {
+	limit int
+
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (c *bodyCapture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := len(p)
+	if rest := c.limit - c.buf.Len(); len(p) > rest {
+		p = p[:rest]
+		c.truncated = true
+	}
+	c.buf.Write(p)
+	return n, nil
+}
+
+func (c *bodyCapture) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.truncated {
+		return c.buf.String() + "..."
+	}
+	return c.buf.String()
+}
+
+// peekBody reads up to limit bytes of the response body without consuming them.
+func peekBody(response *http.Response, limit int) *bodyCapture {
+	c := &bodyCapture{limit: limit}
+
+	buf := make([]byte, limit+1)
+	n, err := io.ReadFull(response.Body, buf)
+	c.Write(buf[:n])
+
+	rest := io.Reader(response.Body)
+	if err != nil {
+		// the body is completely read, keep a possible read error for the caller
+		rest = &errReader{err}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			rest = bytes.NewReader(nil)
+		}
+	}
+
+	response.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf[:n]), rest), response.Body}
+
+	return c
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+func (i *Idb) logRequest(logger *slog.Logger, r *http.Request, requestBody *bodyCapture, response *http.Response, duration time.Duration, err error) {
+	attrs := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("url", redactURL(r.URL)),
+		slog.Duration("duration", duration),
+	}
+
+	if requestBody != nil {
+		attrs = append(attrs, slog.String("request_body", requestBody.String()))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+		logger.LogAttrs(r.Context(), slog.LevelDebug, "IDB request failed", attrs...)
+		return
+	}
+
+	attrs = append(attrs, slog.Int("status", response.StatusCode))
+	if i.LogBodySize > 0 {
+		attrs = append(attrs, slog.String("response_body", peekBody(response, i.LogBodySize).String()))
+	}
+
+	logger.LogAttrs(r.Context(), slog.LevelDebug, "IDB request", attrs...)
+}
@@ -0,0 +1,198 @@
+package idbclient
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+const testToken = "SECRETTOKEN"
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+var redactTests = []struct {
+	u        string
+	expected string
+}{
+	{"https://idb.example.com/api/v2/machines", "https://idb.example.com/api/v2/machines"},
+	{"https://idb.example.com/api/v2/machines?fqdn=a&idb_api_token=" + testToken, "https://idb.example.com/api/v2/machines?fqdn=a&idb_api_token=REDACTED"},
+	{"https://idb.example.com/?idb_api_token=" + testToken + "&idb_api_token=other", "https://idb.example.com/?idb_api_token=REDACTED"},
+}
+
+func TestRedactURL(t *testing.T) {
+	for _, v := range redactTests {
+		u, err := url.Parse(v.u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r := redactURL(u); r != v.expected {
+			t.Logf("%v: %v, expected %v", v.u, r, v.expected)
+			t.Fail()
+		}
+	}
+}
+
+var peekTests = []struct {
+	body     string
+	limit    int
+	expected string
+}{
+	{"", 4, ""},
+	{"abcd", 4, "abcd"},
+	{"abcdef", 4, "abcd..."},
+	{"ab", 4, "ab"},
+}
+
+func TestPeekBody(t *testing.T) {
+	for _, v := range peekTests {
+		response := &http.Response{Body: io.NopCloser(strings.NewReader(v.body))}
+
+		c := peekBody(response, v.limit)
+		if c.String() != v.expected {
+			t.Logf("%q limited to %v: %q, expected %q", v.body, v.limit, c, v.expected)
+			t.Fail()
+		}
+
+		// the body is still completely readable
+		body, err := io.ReadAll(response.Body)
+		if err != nil || string(body) != v.body {
+			t.Logf("%q: body after peek %q %v", v.body, body, err)
+			t.Fail()
+		}
+	}
+
+	readErr := errors.New("read failed")
+	response := &http.Response{Body: io.NopCloser(io.MultiReader(strings.NewReader("ab"), &errReader{readErr}))}
+	peekBody(response, 4)
+	_, err := io.ReadAll(response.Body)
+	if err != readErr {
+		t.Log("read error after peek:", err)
+		t.Fail()
+	}
+}
+
+func testLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func TestLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(`{"fqdn":"a.example.com","cores":2}`))
+	}))
+	defer server.Close()
+
+	i, err := NewIdb(server.URL, testToken, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	i.Logger = testLogger(&buf)
+
+	_, err = i.GetMachine("a.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := buf.String()
+	if !strings.Contains(line, "IDB request") || !strings.Contains(line, "status=200") || strings.Contains(line, "response_body") {
+		t.Log("log without bodies:", line)
+		t.Fail()
+	}
+
+	buf.Reset()
+	i.LogBodySize = 8
+	_, err = i.UpdateMachine(&machine.Machine{Fqdn: "a.example.com"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line = buf.String()
+	if !strings.Contains(line, `request_body="{\"fqdn\":..."`) || !strings.Contains(line, `response_body="{\"fqdn\":..."`) {
+		t.Log("log with bodies:", line)
+		t.Fail()
+	}
+
+	if strings.Contains(line, testToken) {
+		t.Log("token logged:", line)
+		t.Fail()
+	}
+}
+
+func TestLogStreamedBody(t *testing.T) {
+	i, err := NewIdb("https://idb.example.com", testToken, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the server answers before the request body is sent completely
+	sent := make(chan struct{})
+	i.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		go func() {
+			io.Copy(io.Discard, r.Body)
+			r.Body.Close()
+			close(sent)
+		}()
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})
+
+	var buf bytes.Buffer
+	i.Logger = testLogger(&buf)
+	i.LogBodySize = 1 << 20
+
+	r, err := http.NewRequest("POST", "https://idb.example.com/api/v2/machines", strings.NewReader(strings.Repeat("x", 1<<20)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := i.request(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	<-sent
+}
+
+func TestTokenNotLeaked(t *testing.T) {
+	i, err := NewIdb("https://idb.example.com", testToken, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	var buf bytes.Buffer
+	i.Logger = testLogger(&buf)
+
+	var observed error
+	i.Observer = ObserverFunc(func(info RequestInfo) { observed = info.Err })
+
+	_, err = i.GetMachine("a.example.com")
+	if err == nil {
+		t.Fatal("request succeeded")
+	}
+
+	for what, s := range map[string]string{"error": err.Error(), "log": buf.String(), "observed error": observed.Error()} {
+		if strings.Contains(s, testToken) {
+			t.Logf("token in %v: %v", what, s)
+			t.Fail()
+		}
+		if !strings.Contains(s, "connection refused") {
+			t.Logf("cause missing in %v: %v", what, s)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package machine
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/idb-project/idbclient/machine/internal/codecgen"
+)
+
+// TestCodecGenerated fails if machine_codec.go is stale. Run "go generate" after changing Machine.
+func TestCodecGenerated(t *testing.T) {
+	src, err := os.ReadFile("machine.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := codecgen.Generate("machine.go", src, "Machine", "-type=Machine machine.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generated, err := os.ReadFile("machine_codec.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(generated, expected) {
+		t.Error("machine_codec.go is stale, run go generate")
+	}
+}
+
+func TestEqualCreateMachine(t *testing.T) {
+	m1 := Machine{Fqdn: "test10"}
+	m2 := Machine{Fqdn: "test10", CreateMachine: true}
+	if Equal(&m1, &m2) {
+		t.Log("CreateMachine not compared")
+		t.Fail()
+	}
+}
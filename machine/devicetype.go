@@ -1,5 +1,7 @@
 package machine
 
+import "strconv"
+
 // DeviceType mapping from idb/config/application.yml.
 // Let's hope that the mapping doesn't get changed.
 type DeviceType int
@@ -10,3 +12,13 @@ const (
 	DeviceTypeVirtual
 	DeviceTypeSwitch
 )
+
+var deviceTypeNames = []string{"none", "physical", "virtual", "switch"}
+
+// Name returns the lower case name of the device type, e.g. "virtual", or its number if unknown.
+func (t DeviceType) Name() string {
+	if t < 0 || int(t) >= len(deviceTypeNames) {
+		return strconv.Itoa(int(t))
+	}
+	return deviceTypeNames[t]
+}
@@ -0,0 +1,34 @@
+package machine
+
+import "sort"
+
+// Change is a difference of a single field between two machines.
+// Field is the JSON name of the field, Old and New are the JSON decoded values, nil if unset.
+type Change struct {
+	Field string
+	Old   interface{}
+	New   interface{}
+}
+
+// Diff returns the fields which have to be changed to turn current into desired, sorted by field name.
+// Fields with zero values in desired are not compared, as they are omitted in updates.
+func Diff(current, desired *Machine) ([]Change, error) {
+	c, err := Fields(current)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := Fields(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	for _, k := range diffFieldsMachine(current, desired) {
+		changes = append(changes, Change{k, c[k], d[k]})
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	return changes, nil
+}
@@ -0,0 +1,134 @@
+package machine
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// field is an entry of the generated field registry.
+type field struct {
+	// JSON name, Go name and Go type of the field.
+	name   string
+	goName string
+	typ    string
+
+	get  func(m *Machine) interface{}
+	set  func(m *Machine, value string) error
+	copy func(dst, src *Machine)
+}
+
+// FieldInfo describes a machine field.
+type FieldInfo struct {
+	// JSON name, e.g. "pending_security_updates".
+	Name string
+
+	// Name and type of the Machine struct field, e.g. "PendingSecurityUpdates" and "int".
+	GoName string
+	Type   string
+}
+
+var fieldNames []string
+
+// fieldsByName maps JSON field names to registry entries.
+var fieldsByName = make(map[string]*field)
+
+func init() {
+	for i := range fieldsMachine {
+		f := &fieldsMachine[i]
+		fieldNames = append(fieldNames, f.name)
+		fieldsByName[f.name] = f
+	}
+}
+
+func lookup(name string) (*field, error) {
+	f, ok := fieldsByName[name]
+	if !ok {
+		return nil, &ErrUnknownField{name}
+	}
+	return f, nil
+}
+
+// FieldList returns all machine fields in wire order. CreateMachine is not included.
+func FieldList() []FieldInfo {
+	infos := make([]FieldInfo, len(fieldsMachine))
+	for i, f := range fieldsMachine {
+		infos[i] = FieldInfo{f.name, f.goName, f.typ}
+	}
+	return infos
+}
+
+// LookupField returns the field with the JSON name name.
+func LookupField(name string) (FieldInfo, error) {
+	f, err := lookup(name)
+	if err != nil {
+		return FieldInfo{}, err
+	}
+	return FieldInfo{f.name, f.goName, f.typ}, nil
+}
+
+// Get returns the value of the field with the JSON name name, e.g. an int for "cores" or a
+// time.Time for "created_at".
+func Get(m *Machine, name string) (interface{}, error) {
+	f, err := lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.get(m), nil
+}
+
+func equalSlice[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// FieldNames returns the JSON names of all machine fields.
+func FieldNames() []string {
+	names := make([]string, len(fieldNames))
+	copy(names, fieldNames)
+	return names
+}
+
+// Fields returns the fields of m set in its JSON representation, keyed by JSON name.
+// Numbers are decoded as json.Number. CreateMachine is not included.
+func Fields(m *Machine) (map[string]interface{}, error) {
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{})
+
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	err = dec.Decode(&fields)
+	if err != nil {
+		return nil, err
+	}
+
+	// only used to signal the IDB to create a machine
+	delete(fields, "create_machine")
+
+	return fields, nil
+}
+
+// Select returns a copy of m containing only Fqdn and the fields with the given JSON names.
+func Select(m *Machine, names ...string) (*Machine, error) {
+	s := &Machine{Fqdn: m.Fqdn}
+
+	for _, name := range names {
+		f, err := lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		f.copy(s, m)
+	}
+
+	return s, nil
+}
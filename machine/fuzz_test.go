@@ -0,0 +1,196 @@
+package machine
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// randomTime returns a time with nanoseconds in a random fixed zone, or the zero time.
+func randomTime(r *rand.Rand) time.Time {
+	if r.Intn(4) == 0 {
+		return time.Time{}
+	}
+
+	offset := (r.Intn(28*60) - 14*60) * 60
+	zone := time.FixedZone("", offset)
+	if r.Intn(2) == 0 {
+		zone = time.UTC
+	}
+
+	return time.Unix(r.Int63n(4102444800), r.Int63n(int64(time.Second))).In(zone)
+}
+
+// randomValue fills v with random values, using quick.Value for all but time.Time fields.
+func randomValue(v reflect.Value, r *rand.Rand) {
+	switch {
+	case v.Type() == timeType:
+		v.Set(reflect.ValueOf(randomTime(r)))
+	case v.Kind() == reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			randomValue(v.Field(i), r)
+		}
+	case v.Kind() == reflect.Slice:
+		n := r.Intn(3)
+		s := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			randomValue(s.Index(i), r)
+		}
+		v.Set(s)
+	default:
+		q, _ := quick.Value(v.Type(), r)
+		v.Set(q)
+	}
+}
+
+// randomMachine generates arbitrary machines for quick.Check.
+type randomMachine struct {
+	m Machine
+}
+
+func (randomMachine) Generate(r *rand.Rand, size int) reflect.Value {
+	var rm randomMachine
+	randomValue(reflect.ValueOf(&rm.m).Elem(), r)
+	return reflect.ValueOf(rm)
+}
+
+// truncateTimes returns m with all times truncated to whole seconds, as written by TimeFormatLegacy.
+func truncateTimes(m Machine) Machine {
+	v := reflect.ValueOf(&m).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Field(i); f.Type() == timeType {
+			f.Set(reflect.ValueOf(f.Interface().(time.Time).Truncate(time.Second)))
+		}
+	}
+	return m
+}
+
+func TestRoundTrip(t *testing.T) {
+	f := func(rm randomMachine) bool {
+		buf, err := json.Marshal(rm.m)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+
+		var m Machine
+		err = json.Unmarshal(buf, &m)
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+
+		expected := truncateTimes(rm.m)
+		if !Equal(&m, &expected) || m.CreateMachine != rm.m.CreateMachine {
+			t.Logf("%s\n%+v\n%+v", buf, expected, m)
+			return false
+		}
+		return true
+	}
+
+	err := quick.Check(f, &quick.Config{MaxCount: 1000})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// TestParseLayouts checks that every accepted layout parses its own output to the same instant,
+// up to the precision of the layout.
+func TestParseLayouts(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, layout := range parseLayouts {
+		for n := 0; n < 100; n++ {
+			// layouts without zone are interpreted as UTC
+			expected := randomTime(r).UTC()
+
+			s := expected.Format(layout)
+			parsed, err := WireTime{}.parseTime(parseLayouts, s)
+			if err != nil {
+				t.Errorf("%q: %v", s, err)
+				continue
+			}
+
+			// parsing the result of the layout itself must agree with parseTime
+			direct, err := time.Parse(layout, s)
+			if err != nil {
+				t.Errorf("%v: %q: %v", layout, s, err)
+				continue
+			}
+
+			if !parsed.Equal(direct) {
+				t.Errorf("%v: %q parsed as %v, expected %v", layout, s, parsed, direct)
+			}
+		}
+	}
+}
+
+func FuzzParseTime(f *testing.F) {
+	for _, layout := range parseLayouts {
+		f.Add(testTime.Format(layout))
+	}
+	f.Add("2017-03-04 05:06:07.123456")
+	f.Add("2017-03-04T05:06:07+02:00")
+	f.Add("Mon, 01 Jan 0000 00:30:00 +0100")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		var w WireTime
+		p, err := w.parseTime(parseLayouts, s)
+		if err != nil {
+			return
+		}
+
+		// RFC 3339 must preserve every parsed time, the legacy format up to the second
+		for _, w := range []WireTime{{Format: TimeFormatRFC3339}, {Format: TimeFormatLegacy}} {
+			expected := p
+			if w.Format == TimeFormatLegacy {
+				expected = p.Truncate(time.Second)
+			}
+
+			q, err := w.parseTime(parseLayouts, w.formatTime(p))
+			if err != nil {
+				t.Fatalf("%q parsed as %v, formatted as %q: %v", s, p, w.formatTime(p), err)
+			}
+			if !expected.Equal(q) {
+				t.Fatalf("%q parsed as %v, after formatting as %q: %v", s, p, w.formatTime(p), q)
+			}
+		}
+	})
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	for _, v := range marshalTests {
+		f.Add(v.j)
+	}
+	f.Add(`{"fqdn":"a","created_at":"2017-03-04T05:06:07.5+02:00","cores":2,"create_machine":"true"}`)
+
+	// RFC 3339 keeps fractional seconds
+	w := WireTime{Format: TimeFormatRFC3339}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		var m1 Machine
+		if json.Unmarshal([]byte(s), &m1) != nil {
+			return
+		}
+
+		buf, err := json.Marshal(w.JSON(&m1))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var m2 Machine
+		err = json.Unmarshal(buf, w.JSON(&m2))
+		if err != nil {
+			t.Fatalf("%s: %v", buf, err)
+		}
+
+		if !Equal(&m1, &m2) || m1.CreateMachine != m2.CreateMachine {
+			t.Fatalf("round trip of %q changed the machine:\n%+v\n%+v", s, m1, m2)
+		}
+	})
+}
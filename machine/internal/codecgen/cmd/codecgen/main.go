@@ -0,0 +1,52 @@
+// Command codecgen generates the JSON codec, equality, diff and field registry of a struct,
+// see package codecgen.
+//
+// Usage:
+//
+//	codecgen -type=Machine [-output=file] file.go
+//
+// The default output file is <type>_codec.go in lower case.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/idb-project/idbclient/machine/internal/codecgen"
+)
+
+func main() {
+	typeName := flag.String("type", "", "struct type name")
+	output := flag.String("output", "", "output file name")
+	flag.Parse()
+
+	if *typeName == "" || flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: codecgen -type=T [-output=file] file.go")
+		os.Exit(2)
+	}
+
+	filename := flag.Arg(0)
+	if *output == "" {
+		*output = strings.ToLower(*typeName) + "_codec.go"
+	}
+
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "codecgen:", err)
+		os.Exit(1)
+	}
+
+	code, err := codecgen.Generate(filename, src, *typeName, strings.Join(os.Args[1:], " "))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "codecgen:", err)
+		os.Exit(1)
+	}
+
+	err = os.WriteFile(*output, code, 0644)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "codecgen:", err)
+		os.Exit(1)
+	}
+}
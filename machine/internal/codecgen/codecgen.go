@@ -0,0 +1,321 @@
+// Package codecgen generates the JSON codec, equality, diff and field registry of a struct from its
+// json struct tags.
+//
+// time.Time fields are encoded as strings with the methods formatTime and parseTime of the type
+// WireTime, which must be defined in the package of the struct. They are written after all other
+// fields. The generated code for a type T consists of:
+//
+//	jsonT                  the wire representation of T
+//	T.unmarshalJSON        decoding with a WireTime, keeping time fields absent on the wire
+//	T.marshalJSON          encoding with a WireTime
+//	T.UnmarshalJSON        decoding with the zero WireTime
+//	T.MarshalJSON          encoding with the zero WireTime
+//	equalT                 field by field equality
+//	diffFieldsT            names of fields set in desired which differ from current
+//	fieldsT                the field registry, a []field in wire order
+//
+// Fields tagged codec:"-" are encoded, but not part of the registry and diffs.
+//
+// The package must define the registry type field, with the fields name, goName, typ, get, set and copy,
+// the function equalSlice[T comparable](a, b []T) bool and a function converting strings for each field
+// type, see Field.Parser.
+package codecgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// Field is a struct field with a json tag.
+type Field struct {
+	// Name of the struct field.
+	GoName string
+
+	// Type of the struct field as written in the source.
+	Type string
+
+	// JSON name and options from the tag.
+	Name      string
+	OmitEmpty bool
+	String    bool
+
+	// Kind selects the generated code: "time" for time.Time, "slice" for slices, "value" otherwise.
+	Kind string
+
+	// Excluded from the registry and diffs.
+	Excluded bool
+}
+
+// Tag returns the json struct tag of the wire representation.
+func (f Field) Tag() string {
+	tag := f.Name
+	if f.OmitEmpty {
+		tag += ",omitempty"
+	}
+	if f.String {
+		tag += ",string"
+	}
+	return fmt.Sprintf("`json:%q`", tag)
+}
+
+// WireType returns the type of the field in the wire representation.
+func (f Field) WireType() string {
+	if f.Kind == "time" {
+		return "string"
+	}
+	return f.Type
+}
+
+// Parser returns the name of the function converting strings to the type of the field, e.g.
+// parseIntField for int, parseTimeField for time.Time and parseNicsField for []Nic.
+func (f Field) Parser() string {
+	name := f.Type
+	if strings.HasPrefix(name, "[]") {
+		name = name[2:] + "s"
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return "parse" + strings.ToUpper(name[:1]) + name[1:] + "Field"
+}
+
+// IsSet returns an expression testing whether the field of v is not empty in the JSON encoding.
+func (f Field) IsSet(v string) string {
+	switch {
+	case f.Kind == "time":
+		return fmt.Sprintf("!%v.%v.IsZero()", v, f.GoName)
+	case f.Kind == "slice":
+		return fmt.Sprintf("len(%v.%v) != 0", v, f.GoName)
+	case f.Type == "bool":
+		return fmt.Sprintf("%v.%v", v, f.GoName)
+	case f.Type == "string":
+		return fmt.Sprintf("%v.%v != \"\"", v, f.GoName)
+	default:
+		return fmt.Sprintf("%v.%v != 0", v, f.GoName)
+	}
+}
+
+// Fields parses src and returns its package name and the fields of the struct typeName.
+func Fields(filename string, src []byte, typeName string) (string, []Field, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var st *ast.StructType
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ts, ok := n.(*ast.TypeSpec); ok && ts.Name.Name == typeName {
+			st, _ = ts.Type.(*ast.StructType)
+		}
+		return st == nil
+	})
+	if st == nil {
+		return "", nil, fmt.Errorf("%v: struct %v not found", filename, typeName)
+	}
+
+	var fields []Field
+	for _, f := range st.Fields.List {
+		if f.Tag == nil || len(f.Names) != 1 {
+			return "", nil, fmt.Errorf("%v: fields of %v need a json tag and a single name", fset.Position(f.Pos()), typeName)
+		}
+
+		structTag := reflect.StructTag(strings.Trim(f.Tag.Value, "`"))
+		tag := structTag.Get("json")
+		parts := strings.Split(tag, ",")
+		if parts[0] == "" {
+			return "", nil, fmt.Errorf("%v: field %v has no json name", fset.Position(f.Pos()), f.Names[0].Name)
+		}
+
+		field := Field{
+			GoName: f.Names[0].Name,
+			Type:   types.ExprString(f.Type),
+			Name:   parts[0],
+			Kind:   "value",
+
+			Excluded: structTag.Get("codec") == "-",
+		}
+		for _, o := range parts[1:] {
+			switch o {
+			case "omitempty":
+				field.OmitEmpty = true
+			case "string":
+				field.String = true
+			}
+		}
+
+		switch f.Type.(type) {
+		case *ast.ArrayType:
+			field.Kind = "slice"
+		}
+		if field.Type == "time.Time" {
+			field.Kind = "time"
+		}
+
+		fields = append(fields, field)
+	}
+
+	return file.Name.Name, fields, nil
+}
+
+// wireOrder returns the fields with time fields last.
+func wireOrder(fields []Field) []Field {
+	var ordered, times []Field
+	for _, f := range fields {
+		if f.Kind == "time" {
+			times = append(times, f)
+		} else {
+			ordered = append(ordered, f)
+		}
+	}
+	return append(ordered, times...)
+}
+
+var codeTemplate = template.Must(template.New("code").Parse(`// Code generated by "codecgen {{.Args}}"; DO NOT EDIT.
+
+package {{.Package}}
+
+import "encoding/json"
+
+type json{{.Type}} struct {
+{{- range .Wire}}
+	{{.GoName}} {{.WireType}} {{.Tag}}
+{{- end}}
+}
+
+func (m *{{.Type}}) UnmarshalJSON(buf []byte) error {
+	return m.unmarshalJSON(buf, WireTime{})
+}
+
+func (m *{{.Type}}) unmarshalJSON(buf []byte, w WireTime) error {
+	var jm json{{.Type}}
+
+	err := json.Unmarshal(buf, &jm)
+	if err != nil {
+		return err
+	}
+{{range .Fields}}
+{{- if eq .Kind "time"}}
+	if jm.{{.GoName}} != "" {
+		m.{{.GoName}}, err = w.parseTime(parseLayouts, jm.{{.GoName}})
+		if err != nil {
+			return err
+		}
+	}
+{{else}}
+	m.{{.GoName}} = jm.{{.GoName}}
+{{- end}}
+{{- end}}
+
+	return nil
+}
+
+func (m {{.Type}}) MarshalJSON() ([]byte, error) {
+	return m.marshalJSON(WireTime{})
+}
+
+func (m *{{.Type}}) marshalJSON(w WireTime) ([]byte, error) {
+	var jm json{{.Type}}
+{{range .Fields}}
+{{- if eq .Kind "time"}}
+	if !m.{{.GoName}}.IsZero() {
+		jm.{{.GoName}} = w.formatTime(m.{{.GoName}})
+	}
+{{else}}
+	jm.{{.GoName}} = m.{{.GoName}}
+{{- end}}
+{{- end}}
+
+	return json.Marshal(jm)
+}
+
+// equal{{.Type}} tests for equality of all fields. Times are equal if they are the same instant.
+func equal{{.Type}}(m1, m2 *{{.Type}}) bool {
+{{- range .Fields}}
+{{- if eq .Kind "time"}}
+	if !m1.{{.GoName}}.Equal(m2.{{.GoName}}) {
+		return false
+	}
+{{- else if eq .Kind "slice"}}
+	if !equalSlice(m1.{{.GoName}}, m2.{{.GoName}}) {
+		return false
+	}
+{{- else}}
+	if m1.{{.GoName}} != m2.{{.GoName}} {
+		return false
+	}
+{{- end}}
+{{- end}}
+
+	return true
+}
+
+// diffFields{{.Type}} returns the JSON names of the fields which are set in desired and differ from
+// current, in wire order. Fields with omitempty are unset if they are empty in the JSON encoding.
+func diffFields{{.Type}}(current, desired *{{.Type}}) []string {
+	var names []string
+{{range .Wire}}
+{{- if not .Excluded}}
+	if {{if .OmitEmpty}}{{.IsSet "desired"}} && {{end}}
+{{- if eq .Kind "time"}}!current.{{.GoName}}.Equal(desired.{{.GoName}})
+{{- else if eq .Kind "slice"}}!equalSlice(current.{{.GoName}}, desired.{{.GoName}})
+{{- else}}current.{{.GoName}} != desired.{{.GoName}}
+{{- end}} {
+		names = append(names, {{printf "%q" .Name}})
+	}
+{{- end}}
+{{- end}}
+
+	return names
+}
+
+// fields{{.Type}} is the registry of all fields of {{.Type}} in wire order.
+var fields{{.Type}} = []field{
+{{- range .Wire}}
+{{- if not .Excluded}}
+	{
+		name: {{printf "%q" .Name}}, goName: {{printf "%q" .GoName}}, typ: {{printf "%q" .Type}},
+		get:  func(m *{{$.Type}}) interface{} { return m.{{.GoName}} },
+		set:  func(m *{{$.Type}}, v string) (err error) { m.{{.GoName}}, err = {{.Parser}}(v); return err },
+		copy: func(dst, src *{{$.Type}}) { dst.{{.GoName}} = src.{{.GoName}} },
+	},
+{{- end}}
+{{- end}}
+}
+`))
+
+// Generate returns the generated code for the struct typeName in src. args are recorded in the header
+// of the generated code.
+func Generate(filename string, src []byte, typeName, args string) ([]byte, error) {
+	pkg, fields, err := Fields(filename, src, typeName)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	err = codeTemplate.Execute(&b, map[string]interface{}{
+		"Args":    args,
+		"Package": pkg,
+		"Type":    typeName,
+		"Fields":  fields,
+		"Wire":    wireOrder(fields),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v\n%s", err, b.Bytes())
+	}
+
+	return code, nil
+}
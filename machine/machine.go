@@ -1,403 +1,206 @@
 package machine
 
 import (
-	"encoding/json"
+	"fmt"
 	"time"
 )
 
-var parseLayouts = []string{"2006-01-02T15:04:05.9999Z", "2006-01-02 15:04:05", "2006-01-02", time.RFC3339Nano, time.ANSIC, time.UnixDate, time.RubyDate, time.RFC822, time.RFC822Z, time.RFC850, time.RFC1123, time.RFC1123Z, time.RFC3339, time.Kitchen, time.Stamp, time.StampMilli, time.StampMicro, time.StampNano}
+var parseLayouts = []string{"2006-01-02T15:04:05.9999Z07:00", "2006-01-02 15:04:05", "2006-01-02", time.RFC3339Nano, time.ANSIC, time.UnixDate, time.RubyDate, time.RFC822, time.RFC822Z, time.RFC850, time.RFC1123, time.RFC1123Z, time.RFC3339, time.Kitchen, time.Stamp, time.StampMilli, time.StampMicro, time.StampNano}
 
+// formatLayout is the legacy wire format of times.
 var formatLayout = "2006-01-02 15:04:05"
 
-func parseTime(layouts []string, value string) (time.Time, error) {
-	var err error
-	for _, v := range layouts {
-		var t time.Time
-		t, err = time.Parse(v, value)
-
-		// continue to the next layout
-		if err != nil {
-			continue
-		}
-
-		// parsed successfully
-		return t, nil
+// formatTime formats t in the location and format of w.
+func (w WireTime) formatTime(t time.Time) string {
+	if w.Format == TimeFormatRFC3339 {
+		return t.In(w.location()).Format(time.RFC3339Nano)
 	}
-
-	return time.Time{}, err
+	return t.In(w.location()).Format(formatLayout)
 }
 
-type jsonMachine struct {
-	Fqdn                                  string      `json:"fqdn"`
-	Os                                    string      `json:"os,omitempty"`
-	Arch                                  string      `json:"arch,omitempty"`
-	RAM                                   int         `json:"ram,omitempty"`
-	Cores                                 int         `json:"cores,omitempty"`
-	Diskspace                             int         `json:"diskspace,omitempty"`
-	Vmhost                                string      `json:"vmhost,omitempty"`
-	Description                           string      `json:"description,omitempty"`
-	OsRelease                             string      `json:"os_release,omitempty"`
-	Uptime                                int         `json:"uptime,omitempty"`
-	DeviceTypeID                          DeviceType  `json:"device_type_id,omitempty"`
-	Serialnumber                          string      `json:"serialnumber,omitempty"`
-	OwnerID                               int         `json:"owner_id,omitempty"`
-	AutoUpdate                            bool        `json:"auto_update,omitempty"`
-	SwitchURL                             string      `json:"switch_url,omitempty"`
-	MrtgURL                               string      `json:"mrtg_url,omitempty"`
-	ConfigInstructions                    string      `json:"config_instructions,omitempty"`
-	SwCharacteristics                     string      `json:"sw_characteristics,omitempty"`
-	BusinessPurpose                       string      `json:"business_purpose,omitempty"`
-	BusinessCriticality                   string      `json:"business_criticality,omitempty"`
-	BusinessNotification                  string      `json:"business_notification,omitempty"`
-	UnattendedUpgrades                    bool        `json:"unattended_upgrades,omitempty"`
-	UnattendedUpgradesBlacklistedPackages string      `json:"unattended_upgrades_blacklisted_packages,omitempty"`
-	UnattendedUpgradesReboot              bool        `json:"unattended_upgrades_reboot,omitempty"`
-	UnattendedUpgradesTime                string      `json:"unattended_upgrades_time,omitempty"`
-	UnattendedUpgradesRepos               string      `json:"unattended_upgrades_repos,omitempty"`
-	PendingUpdates                        int         `json:"pending_updates,omitempty"`
-	PendingSecurityUpdates                int         `json:"pending_security_updates,omitempty"`
-	PendingUpdatesSum                     int         `json:"pending_updates_sum,omitempty"`
-	PendingUpdatesPackageNames            string      `json:"pending_updates_package_names,omitempty"`
-	SeverityClass                         string      `json:"severity_class,omitempty"`
-	UcsRole                               string      `json:"ucs_role,omitempty"`
-	BackupType                            BackupType  `json:"backup_type,omitempty"`
-	BackupBrand                           BackupBrand `json:"backup_brand,omitempty"`
-	BackupLastFullSize                    int64       `json:"backup_last_full_size,omitempty"`
-	BackupLastIncSize                     int64       `json:"backup_last_inc_size,omitempty"`
-	BackupLastDiffSize                    int64       `json:"backup_last_diff_size,omitempty"`
-	Nics                                  []Nic       `json:"nics,omitempty"`
-
-	CreateMachine bool `json:"create_machine,string"`
-
-	// time.Time fields need special handling for omitempty / unmarshalling if multiple formats can occur
-	ServicedAt string `json:"serviced_at,omitempty"`
-	DeletedAt  string `json:"deleted_at,omitempty"`
-	CreatedAt  string `json:"created_at,omitempty"`
-	UpdatedAt  string `json:"updated_at,omitempty"`
-
-	BackupLastFullRun string `json:"backup_last_full_run,omitempty"`
-	BackupLastIncRun  string `json:"backup_last_inc_run,omitempty"`
-	BackupLastDiffRun string `json:"backup_last_diff_run,omitempty"`
+// ErrTimeRange is returned for times which can't be represented in the wire format, i.e. outside
+// the years 0 to 9999 in the wire location.
+type ErrTimeRange struct {
+	value string
 }
 
-func (m *Machine) UnmarshalJSON(buf []byte) error {
-	var jm jsonMachine
-
-	err := json.Unmarshal(buf, &jm)
-	if err != nil {
-		return err
-	}
-
-	m.Fqdn = jm.Fqdn
-	m.Os = jm.Os
-	m.Arch = jm.Arch
-	m.RAM = jm.RAM
-	m.Cores = jm.Cores
-	m.Diskspace = jm.Diskspace
-	m.Vmhost = jm.Vmhost
-	m.Description = jm.Description
-
-	if jm.ServicedAt != "" {
-		m.ServicedAt, err = parseTime(parseLayouts, jm.ServicedAt)
-		if err != nil {
-			return err
-		}
-	}
-
-	if jm.DeletedAt != "" {
-		m.DeletedAt, err = parseTime(parseLayouts, jm.DeletedAt)
-		if err != nil {
-			return err
-		}
-	}
+func (e *ErrTimeRange) Error() string {
+	return fmt.Sprintf("time %q out of range", e.value)
+}
 
-	if jm.CreatedAt != "" {
-		m.CreatedAt, err = parseTime(parseLayouts, jm.CreatedAt)
-		if err != nil {
-			return err
-		}
-	}
+// parseTime parses value with the first matching layout. Values without zone are interpreted in the
+// location of w, and the result is converted to it.
+func (w WireTime) parseTime(layouts []string, value string) (time.Time, error) {
+	loc := w.location()
 
-	if jm.UpdatedAt != "" {
-		m.UpdatedAt, err = parseTime(parseLayouts, jm.UpdatedAt)
-		if err != nil {
-			return err
-		}
-	}
+	var err error
+	for _, v := range layouts {
+		var t time.Time
+		t, err = time.ParseInLocation(v, value, loc)
 
-	m.OsRelease = jm.OsRelease
-	m.Uptime = jm.Uptime
-	m.DeviceTypeID = jm.DeviceTypeID
-	m.Serialnumber = jm.Serialnumber
-	m.OwnerID = jm.OwnerID
-	m.AutoUpdate = jm.AutoUpdate
-	m.SwitchURL = jm.SwitchURL
-	m.MrtgURL = jm.MrtgURL
-	m.ConfigInstructions = jm.ConfigInstructions
-	m.SwCharacteristics = jm.SwCharacteristics
-	m.BusinessPurpose = jm.BusinessPurpose
-	m.BusinessCriticality = jm.BusinessCriticality
-	m.BusinessNotification = jm.BusinessNotification
-	m.UnattendedUpgrades = jm.UnattendedUpgrades
-	m.UnattendedUpgradesBlacklistedPackages = jm.UnattendedUpgradesBlacklistedPackages
-	m.UnattendedUpgradesReboot = jm.UnattendedUpgradesReboot
-	m.UnattendedUpgradesTime = jm.UnattendedUpgradesTime
-	m.UnattendedUpgradesRepos = jm.UnattendedUpgradesRepos
-	m.PendingUpdates = jm.PendingUpdates
-	m.PendingSecurityUpdates = jm.PendingSecurityUpdates
-	m.PendingUpdatesSum = jm.PendingUpdatesSum
-	m.PendingUpdatesPackageNames = jm.PendingUpdatesPackageNames
-	m.SeverityClass = jm.SeverityClass
-	m.UcsRole = jm.UcsRole
-	m.BackupType = jm.BackupType
-	m.BackupBrand = jm.BackupBrand
-
-	if jm.BackupLastFullRun != "" {
-		m.BackupLastFullRun, err = parseTime(parseLayouts, jm.BackupLastFullRun)
+		// continue to the next layout
 		if err != nil {
-			return err
+			continue
 		}
-	}
 
-	if jm.BackupLastIncRun != "" {
-		m.BackupLastIncRun, err = parseTime(parseLayouts, jm.BackupLastIncRun)
-		if err != nil {
-			return err
+		t = t.In(loc)
+		if y := t.Year(); y < 0 || y > 9999 {
+			return time.Time{}, &ErrTimeRange{value}
 		}
-	}
 
-	if jm.BackupLastDiffRun != "" {
-		m.BackupLastDiffRun, err = parseTime(parseLayouts, jm.BackupLastDiffRun)
-		if err != nil {
-			return err
-		}
+		// parsed successfully
+		return t, nil
 	}
 
-	m.BackupLastFullSize = jm.BackupLastFullSize
-	m.BackupLastIncSize = jm.BackupLastIncSize
-	m.BackupLastDiffSize = jm.BackupLastDiffSize
-	m.Nics = jm.Nics
-
-	return nil
+	return time.Time{}, err
 }
 
-func (m Machine) MarshalJSON() ([]byte, error) {
-	var jm jsonMachine
-
-	jm.Fqdn = m.Fqdn
-	jm.Os = m.Os
-	jm.Arch = m.Arch
-	jm.RAM = m.RAM
-	jm.Cores = m.Cores
-	jm.Diskspace = m.Diskspace
-	jm.Vmhost = m.Vmhost
-	jm.Description = m.Description
-
-	if !m.ServicedAt.IsZero() {
-		jm.ServicedAt = m.ServicedAt.Format(formatLayout)
-	}
-
-	if !m.DeletedAt.IsZero() {
-		jm.DeletedAt = m.DeletedAt.Format(formatLayout)
-	}
-
-	if !m.CreatedAt.IsZero() {
-		jm.CreatedAt = m.CreatedAt.Format(formatLayout)
-	}
-
-	if !m.UpdatedAt.IsZero() {
-		jm.UpdatedAt = m.UpdatedAt.Format(formatLayout)
-	}
-
-	jm.OsRelease = m.OsRelease
-	jm.Uptime = m.Uptime
-	jm.DeviceTypeID = m.DeviceTypeID
-	jm.Serialnumber = m.Serialnumber
-	jm.OwnerID = m.OwnerID
-	jm.AutoUpdate = m.AutoUpdate
-	jm.SwitchURL = m.SwitchURL
-	jm.MrtgURL = m.MrtgURL
-	jm.ConfigInstructions = m.ConfigInstructions
-	jm.SwCharacteristics = m.SwCharacteristics
-	jm.BusinessPurpose = m.BusinessPurpose
-	jm.BusinessCriticality = m.BusinessCriticality
-	jm.BusinessNotification = m.BusinessNotification
-	jm.UnattendedUpgrades = m.UnattendedUpgrades
-	jm.UnattendedUpgradesBlacklistedPackages = m.UnattendedUpgradesBlacklistedPackages
-	jm.UnattendedUpgradesReboot = m.UnattendedUpgradesReboot
-	jm.UnattendedUpgradesTime = m.UnattendedUpgradesTime
-	jm.UnattendedUpgradesRepos = m.UnattendedUpgradesRepos
-	jm.PendingUpdates = m.PendingUpdates
-	jm.PendingSecurityUpdates = m.PendingSecurityUpdates
-	jm.PendingUpdatesSum = m.PendingUpdatesSum
-	jm.PendingUpdatesPackageNames = m.PendingUpdatesPackageNames
-	jm.SeverityClass = m.SeverityClass
-	jm.UcsRole = m.UcsRole
-	jm.BackupType = m.BackupType
-	jm.BackupBrand = m.BackupBrand
-
-	if !m.BackupLastFullRun.IsZero() {
-		jm.BackupLastFullRun = m.BackupLastFullRun.Format(formatLayout)
-	}
-
-	if !m.BackupLastIncRun.IsZero() {
-		jm.BackupLastIncRun = m.BackupLastIncRun.Format(formatLayout)
-	}
-
-	if !m.BackupLastDiffRun.IsZero() {
-		jm.BackupLastDiffRun = m.BackupLastDiffRun.Format(formatLayout)
-	}
+//go:generate go run ./internal/codecgen/cmd/codecgen -type=Machine machine.go
 
-	jm.BackupLastFullSize = m.BackupLastFullSize
-	jm.BackupLastIncSize = m.BackupLastIncSize
-	jm.BackupLastDiffSize = m.BackupLastDiffSize
-	jm.Nics = m.Nics
-
-	jm.CreateMachine = m.CreateMachine
-
-	return json.Marshal(jm)
-}
-
-// Machine represents a IDB machine entry.
+// Machine represents a IDB machine entry. The JSON codec, Equal, Diff and the field registry are
+// generated from the json tags, time.Time fields are encoded as configured by WireTime.
 type Machine struct {
 	// Fully qualified domain name of the machine.
-	Fqdn string
+	Fqdn string `json:"fqdn"`
 
 	// Operating system.
-	Os string
+	Os string `json:"os,omitempty"`
 
 	// Machine architecture.
-	Arch string
+	Arch string `json:"arch,omitempty"`
 
 	// Size of the RAM in Mebibytes
-	RAM int
+	RAM int `json:"ram,omitempty"`
 
 	// Number of CPU cores.
-	Cores int
+	Cores int `json:"cores,omitempty"`
 
 	// Disk space in Mebibytes
-	Diskspace int
+	Diskspace int `json:"diskspace,omitempty"`
 
 	// Vmhost
-	Vmhost string
+	Vmhost string `json:"vmhost,omitempty"`
 
 	// Textual description of the machine.
-	Description string
+	Description string `json:"description,omitempty"`
 
 	// Date of last service.
-	ServicedAt time.Time
+	ServicedAt time.Time `json:"serviced_at,omitempty"`
 
 	// Deletion date.
-	DeletedAt time.Time
+	DeletedAt time.Time `json:"deleted_at,omitempty"`
 
 	// Creation date.
-	CreatedAt time.Time
+	CreatedAt time.Time `json:"created_at,omitempty"`
 
 	// Update date.
-	UpdatedAt time.Time
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
 
 	// Operating system release information.
-	OsRelease string
+	OsRelease string `json:"os_release,omitempty"`
 
 	// Machine uptime.
-	Uptime int
+	Uptime int `json:"uptime,omitempty"`
 
 	// Type of the machine (physical, virtual, switch, ...)
-	DeviceTypeID DeviceType
+	DeviceTypeID DeviceType `json:"device_type_id,omitempty"`
 
 	// Device serial number.
-	Serialnumber string
+	Serialnumber string `json:"serialnumber,omitempty"`
 
 	// ID of the owner in IDB.
-	OwnerID int
+	OwnerID int `json:"owner_id,omitempty"`
+
+	// ID of the location in IDB, usually a rack.
+	LocationID int `json:"location_id,omitempty"`
 
 	// ???
-	AutoUpdate bool
+	AutoUpdate bool `json:"auto_update,omitempty"`
 
 	// ???
-	SwitchURL string
+	SwitchURL string `json:"switch_url,omitempty"`
 
 	// ???
-	MrtgURL string
+	MrtgURL string `json:"mrtg_url,omitempty"`
 
 	// ???
-	ConfigInstructions string
+	ConfigInstructions string `json:"config_instructions,omitempty"`
 
 	// ???
-	SwCharacteristics string
+	SwCharacteristics string `json:"sw_characteristics,omitempty"`
 
 	// ???
-	BusinessPurpose string
+	BusinessPurpose string `json:"business_purpose,omitempty"`
 
 	// ???
-	BusinessCriticality string
+	BusinessCriticality string `json:"business_criticality,omitempty"`
 
 	// ???
-	BusinessNotification string
+	BusinessNotification string `json:"business_notification,omitempty"`
 
 	// ???
-	UnattendedUpgrades bool
+	UnattendedUpgrades bool `json:"unattended_upgrades,omitempty"`
 
 	// ???
-	UnattendedUpgradesBlacklistedPackages string
+	UnattendedUpgradesBlacklistedPackages string `json:"unattended_upgrades_blacklisted_packages,omitempty"`
 
 	// ???
-	UnattendedUpgradesReboot bool
+	UnattendedUpgradesReboot bool `json:"unattended_upgrades_reboot,omitempty"`
 
 	// ???
-	UnattendedUpgradesTime string
+	UnattendedUpgradesTime string `json:"unattended_upgrades_time,omitempty"`
 
 	// ???
-	UnattendedUpgradesRepos string
+	UnattendedUpgradesRepos string `json:"unattended_upgrades_repos,omitempty"`
 
 	// ???
-	PendingUpdates int
+	PendingUpdates int `json:"pending_updates,omitempty"`
 
 	// ???
-	PendingSecurityUpdates int
+	PendingSecurityUpdates int `json:"pending_security_updates,omitempty"`
 
 	// ???
-	PendingUpdatesSum int
+	PendingUpdatesSum int `json:"pending_updates_sum,omitempty"`
 
 	// ???
-	PendingUpdatesPackageNames string
+	PendingUpdatesPackageNames string `json:"pending_updates_package_names,omitempty"`
 
 	// ???
-	SeverityClass string
+	SeverityClass string `json:"severity_class,omitempty"`
 
 	// ???
-	UcsRole string
+	UcsRole string `json:"ucs_role,omitempty"`
 
 	// Backup type (if this machine has backups, etc.. See BackupType documentation).
-	BackupType BackupType
+	BackupType BackupType `json:"backup_type,omitempty"`
 
 	// Which product is used for the backup.
-	BackupBrand BackupBrand
+	BackupBrand BackupBrand `json:"backup_brand,omitempty"`
 
 	// Last complete full backup date.
-	BackupLastFullRun time.Time
+	BackupLastFullRun time.Time `json:"backup_last_full_run,omitempty"`
 
 	// Last complete incremental backup date.
-	BackupLastIncRun time.Time
+	BackupLastIncRun time.Time `json:"backup_last_inc_run,omitempty"`
 
 	// Last complete differiential backup date.
-	BackupLastDiffRun time.Time
+	BackupLastDiffRun time.Time `json:"backup_last_diff_run,omitempty"`
 
 	// Sum of the size of all completed full backups
-	BackupLastFullSize int64
+	BackupLastFullSize int64 `json:"backup_last_full_size,omitempty"`
 
 	// Sum of the size of all completed incremental backups
-	BackupLastIncSize int64
+	BackupLastIncSize int64 `json:"backup_last_inc_size,omitempty"`
 
 	// Sum of the size of all completed differential backups
-	BackupLastDiffSize int64
+	BackupLastDiffSize int64 `json:"backup_last_diff_size,omitempty"`
 
 	// Network interfaces
-	Nics []Nic
+	Nics []Nic `json:"nics,omitempty"`
 
 	// Create machine if not existing already. This will be set by the Update method of idbclient.Idb
 	// and is only exported to be visible for marshalling.
-	CreateMachine bool
+	CreateMachine bool `json:"create_machine,string" codec:"-"`
 }
 
 // Backup is a convienience method to fill the fields needed for a update of the backup data.
@@ -418,60 +221,7 @@ func (m *Machine) Backup(fqdn string, brand BackupBrand, lastFull, lastInc, last
 
 // Equal tests for equality of machine objects
 func Equal(m1, m2 *Machine) bool {
-	e := m1.Fqdn == m2.Fqdn
-	e = e && m1.Os == m2.Os
-	e = e && m1.Arch == m2.Arch
-	e = e && m1.RAM == m2.RAM
-	e = e && m1.Cores == m2.Cores
-	e = e && m1.Diskspace == m2.Diskspace
-	e = e && m1.Vmhost == m2.Vmhost
-	e = e && m1.Description == m2.Description
-	e = e && m1.ServicedAt == m2.ServicedAt
-	e = e && m1.DeletedAt == m2.DeletedAt
-	e = e && m1.CreatedAt == m2.CreatedAt
-	e = e && m1.UpdatedAt == m2.UpdatedAt
-	e = e && m1.OsRelease == m2.OsRelease
-	e = e && m1.Uptime == m2.Uptime
-	e = e && m1.DeviceTypeID == m2.DeviceTypeID
-	e = e && m1.Serialnumber == m2.Serialnumber
-	e = e && m1.OwnerID == m2.OwnerID
-	e = e && m1.AutoUpdate == m2.AutoUpdate
-	e = e && m1.SwitchURL == m2.SwitchURL
-	e = e && m1.MrtgURL == m2.MrtgURL
-	e = e && m1.ConfigInstructions == m2.ConfigInstructions
-	e = e && m1.SwCharacteristics == m2.SwCharacteristics
-	e = e && m1.BusinessPurpose == m2.BusinessPurpose
-	e = e && m1.BusinessCriticality == m2.BusinessCriticality
-	e = e && m1.BusinessNotification == m2.BusinessNotification
-	e = e && m1.UnattendedUpgrades == m2.UnattendedUpgrades
-	e = e && m1.UnattendedUpgradesBlacklistedPackages == m2.UnattendedUpgradesBlacklistedPackages
-	e = e && m1.UnattendedUpgradesReboot == m2.UnattendedUpgradesReboot
-	e = e && m1.UnattendedUpgradesTime == m2.UnattendedUpgradesTime
-	e = e && m1.UnattendedUpgradesRepos == m2.UnattendedUpgradesRepos
-	e = e && m1.PendingUpdates == m2.PendingUpdates
-	e = e && m1.PendingSecurityUpdates == m2.PendingSecurityUpdates
-	e = e && m1.PendingUpdatesSum == m2.PendingUpdatesSum
-	e = e && m1.PendingUpdatesPackageNames == m2.PendingUpdatesPackageNames
-	e = e && m1.SeverityClass == m2.SeverityClass
-	e = e && m1.UcsRole == m2.UcsRole
-	e = e && m1.BackupType == m2.BackupType
-	e = e && m1.BackupBrand == m2.BackupBrand
-	e = e && m1.BackupLastFullRun == m2.BackupLastFullRun
-	e = e && m1.BackupLastIncRun == m2.BackupLastIncRun
-	e = e && m1.BackupLastDiffRun == m2.BackupLastDiffRun
-	e = e && m1.BackupLastFullSize == m2.BackupLastFullSize
-	e = e && m1.BackupLastIncSize == m2.BackupLastIncSize
-	e = e && m1.BackupLastDiffSize == m2.BackupLastDiffSize
-
-	if len(m1.Nics) == len(m2.Nics) {
-		for i, v := range m1.Nics {
-			e = e && v == m2.Nics[i]
-		}
-	} else {
-		e = false
-	}
-
-	return e
+	return equalMachine(m1, m2)
 }
 
 // Nic represents a network interface
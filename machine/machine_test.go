@@ -105,3 +105,116 @@ func TestUnequal(t *testing.T) {
 		}
 	}
 }
+
+func TestDiff(t *testing.T) {
+	current := Machine{Fqdn: "test9", Cores: 2, RAM: 1024, Os: "Debian"}
+	desired := Machine{Fqdn: "test9", Cores: 4, Os: "Debian", BackupLastFullRun: testTime}
+
+	changes, err := Diff(&current, &desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("Got %v changes, expected 2: %+v", len(changes), changes)
+	}
+
+	if changes[0].Field != "backup_last_full_run" || changes[0].Old != nil || changes[0].New != "2006-01-02 15:04:05" {
+		t.Errorf("Unexpected change %+v", changes[0])
+	}
+
+	if changes[1].Field != "cores" || changes[1].Old.(json.Number) != "2" || changes[1].New.(json.Number) != "4" {
+		t.Errorf("Unexpected change %+v", changes[1])
+	}
+}
+
+func TestSelect(t *testing.T) {
+	m := Machine{Fqdn: "test10", Cores: 4, RAM: 1024, Nics: []Nic{Nic{Name: "lo"}}, BackupLastFullRun: testTime}
+
+	s, err := Select(&m, "cores", "nics")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Machine{Fqdn: "test10", Cores: 4, Nics: []Nic{Nic{Name: "lo"}}}
+	if !Equal(s, &expected) {
+		t.Errorf("Got %+v, expected %+v", s, expected)
+	}
+
+	_, err = Select(&m, "unknown")
+	if _, ok := err.(*ErrUnknownField); !ok {
+		t.Errorf("Got %v, expected ErrUnknownField", err)
+	}
+}
+
+func TestSet(t *testing.T) {
+	var m Machine
+
+	for _, v := range []struct {
+		name  string
+		value string
+		get   interface{}
+	}{
+		{"os", "Debian", "Debian"},
+		{"pending_security_updates", "3", 3},
+		{"backup_last_full_size", "12345678901", int64(12345678901)},
+		{"auto_update", "true", true},
+		{"device_type_id", "Virtual", DeviceTypeVirtual},
+		{"device_type_id", "1", DeviceTypePhyiscal},
+		{"backup_type", "notneeded", BackupTypeNotNeeded},
+		{"backup_brand", "bacula", BackupBrandBacula},
+		{"backup_last_full_run", "2006-01-02T15:04:05Z", testTime},
+		{"cores", "", 0},
+	} {
+		err := Set(&m, v.name, v.value)
+		if err != nil {
+			t.Errorf("Set(%v, %q): %v", v.name, v.value, err)
+			continue
+		}
+
+		got, err := Get(&m, v.name)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if tm, ok := v.get.(time.Time); ok {
+			if !tm.Equal(got.(time.Time)) {
+				t.Errorf("Get(%v) = %v, expected %v", v.name, got, v.get)
+			}
+		} else if got != v.get {
+			t.Errorf("Get(%v) = %#v, expected %#v", v.name, got, v.get)
+		}
+	}
+
+	err := Set(&m, "nics", `[{"name":"eth0","ip_address":{"addr":"10.0.0.1"}}]`)
+	if err != nil || len(m.Nics) != 1 || m.Nics[0].IPAddress.Addr != "10.0.0.1" {
+		t.Errorf("Setting nics failed: %v %+v", err, m.Nics)
+	}
+
+	for _, v := range [][2]string{{"cores", "many"}, {"auto_update", "maybe"}, {"device_type_id", "4"}, {"backup_brand", "tape"}, {"created_at", "yesterday"}} {
+		err := Set(&m, v[0], v[1])
+		if _, ok := err.(*ErrFieldValue); !ok {
+			t.Errorf("Set(%v, %q): got %v, expected ErrFieldValue", v[0], v[1], err)
+		}
+	}
+
+	if err := Set(&m, "unknown", "1"); err == nil {
+		t.Error("Set of unknown field succeeded")
+	}
+	if _, err := Get(&m, "create_machine"); err == nil {
+		t.Error("Get of create_machine succeeded")
+	}
+}
+
+func TestFieldList(t *testing.T) {
+	fields := FieldList()
+	if len(fields) != len(FieldNames()) {
+		t.Fatalf("Got %v fields, expected %v", len(fields), len(FieldNames()))
+	}
+
+	f, err := LookupField("pending_security_updates")
+	if err != nil || f.GoName != "PendingSecurityUpdates" || f.Type != "int" {
+		t.Errorf("Unexpected field %+v %v", f, err)
+	}
+}
@@ -0,0 +1,145 @@
+package machine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnknownField is returned for unknown field names.
+type ErrUnknownField struct {
+	name string
+}
+
+func (e *ErrUnknownField) Error() string {
+	return fmt.Sprintf("unknown machine field %q", e.name)
+}
+
+// ErrFieldValue is returned by Set for values which can't be converted to the type of the field.
+type ErrFieldValue struct {
+	name  string
+	value string
+	err   error
+}
+
+func (e *ErrFieldValue) Error() string {
+	return fmt.Sprintf("invalid value %q for machine field %v: %v", e.value, e.name, e.err)
+}
+
+func (e *ErrFieldValue) Unwrap() error {
+	return e.err
+}
+
+// Set sets the field with the JSON name name to value, converted to the type of the field:
+//
+//	strings     unchanged
+//	ints        decimal numbers
+//	bools       as accepted by strconv.ParseBool
+//	times       any format accepted when decoding machines, without zone in UTC
+//	enums       numbers or names, matched case insensitive without the type prefix,
+//	            e.g. "virtual", "notneeded" or "bacula"
+//	nics        a JSON array of interfaces
+//
+// An empty value sets the zero value.
+func Set(m *Machine, name, value string) error {
+	f, err := lookup(name)
+	if err != nil {
+		return err
+	}
+
+	err = f.set(m, value)
+	if err != nil {
+		return &ErrFieldValue{name, value, err}
+	}
+
+	return nil
+}
+
+func parseStringField(value string) (string, error) {
+	return value, nil
+}
+
+func parseIntField(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func parseInt64Field(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func parseBoolField(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+func parseTimeField(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return WireTime{}.parseTime(parseLayouts, value)
+}
+
+func parseNicsField(value string) ([]Nic, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var nics []Nic
+	err := json.Unmarshal([]byte(value), &nics)
+	return nics, err
+}
+
+// parseEnum returns the index of value in names, matched case insensitive, or value as number
+// if it is a valid index.
+func parseEnum(value string, names []string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	for i, n := range names {
+		if strings.EqualFold(value, n) {
+			return i, nil
+		}
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 || i >= len(names) {
+		return 0, fmt.Errorf("expected one of %v", strings.Join(names, ", "))
+	}
+	return i, nil
+}
+
+func parseDeviceTypeField(value string) (DeviceType, error) {
+	i, err := parseEnum(value, deviceTypeNames)
+	return DeviceType(i), err
+}
+
+func parseBackupTypeField(value string) (BackupType, error) {
+	var names []string
+	for t := BackupTypeNo; t < BackupTypeEnd; t++ {
+		names = append(names, strings.TrimPrefix(t.String(), "BackupType"))
+	}
+
+	i, err := parseEnum(value, names)
+	return BackupType(i), err
+}
+
+func parseBackupBrandField(value string) (BackupBrand, error) {
+	var names []string
+	for b := BackupBrandNone; b < BackupBrandEnd; b++ {
+		names = append(names, strings.TrimPrefix(b.String(), "BackupBrand"))
+	}
+
+	i, err := parseEnum(value, names)
+	return BackupBrand(i), err
+}
@@ -0,0 +1,108 @@
+package machine
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TimeFormat selects how times are written when marshalling machines.
+type TimeFormat int
+
+const (
+	// TimeFormatLegacy writes "2006-01-02 15:04:05" in the wire location. This is the format the IDB has
+	// always used; fractional seconds are dropped. In locations with daylight saving time, times in the
+	// repeated hour are ambiguous.
+	TimeFormatLegacy TimeFormat = iota
+
+	// TimeFormatRFC3339 writes RFC 3339 times with the offset of the wire location and fractional seconds
+	// if present. The offset makes times unambiguous, also during daylight saving time changes.
+	TimeFormatRFC3339
+)
+
+// WireTime is the location and format of times on the wire. Times without zone are parsed in the
+// location, and all times are converted to it before marshalling and after unmarshalling. The location
+// must match the time zone setting of the IDB.
+//
+// The zero value is UTC with TimeFormatLegacy. It is used by Machine.MarshalJSON and Machine.UnmarshalJSON;
+// use JSON to encode or decode machines with other settings.
+type WireTime struct {
+	// Location of the times on the wire, nil for UTC.
+	Location *time.Location
+
+	Format TimeFormat
+}
+
+func (w WireTime) location() *time.Location {
+	if w.Location == nil {
+		return time.UTC
+	}
+	return w.Location
+}
+
+// JSON wraps v for encoding/json, so the machines in it are encoded and decoded with the times of w.
+// v must be a *Machine or a *[]Machine, other values are returned unchanged.
+func (w WireTime) JSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *Machine:
+		return &wireMachine{v, w}
+	case *[]Machine:
+		return &wireMachines{v, w}
+	}
+	return v
+}
+
+type wireMachine struct {
+	m *Machine
+	w WireTime
+}
+
+func (wm *wireMachine) MarshalJSON() ([]byte, error) {
+	return wm.m.marshalJSON(wm.w)
+}
+
+func (wm *wireMachine) UnmarshalJSON(buf []byte) error {
+	return wm.m.unmarshalJSON(buf, wm.w)
+}
+
+type wireMachines struct {
+	ms *[]Machine
+	w  WireTime
+}
+
+func (wms *wireMachines) MarshalJSON() ([]byte, error) {
+	if *wms.ms == nil {
+		return []byte("null"), nil
+	}
+
+	raw := make([]json.RawMessage, len(*wms.ms))
+	for i := range *wms.ms {
+		var err error
+		raw[i], err = (*wms.ms)[i].marshalJSON(wms.w)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(raw)
+}
+
+func (wms *wireMachines) UnmarshalJSON(buf []byte) error {
+	var raw []json.RawMessage
+	err := json.Unmarshal(buf, &raw)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		*wms.ms = nil
+		return nil
+	}
+
+	ms := make([]Machine, len(raw))
+	for i := range raw {
+		err = ms[i].unmarshalJSON(raw[i], wms.w)
+		if err != nil {
+			return err
+		}
+	}
+	*wms.ms = ms
+	return nil
+}
@@ -0,0 +1,116 @@
+package machine
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/quick"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestWireTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// summer time, UTC+2
+	run := time.Date(2017, 7, 1, 10, 0, 0, 0, time.UTC)
+
+	for _, c := range []struct {
+		w WireTime
+		j string
+	}{
+		{WireTime{}, `"2017-07-01 10:00:00"`},
+		{WireTime{berlin, TimeFormatLegacy}, `"2017-07-01 12:00:00"`},
+		{WireTime{berlin, TimeFormatRFC3339}, `"2017-07-01T12:00:00+02:00"`},
+		{WireTime{time.UTC, TimeFormatRFC3339}, `"2017-07-01T10:00:00Z"`},
+	} {
+		buf, err := json.Marshal(c.w.JSON(&Machine{Fqdn: "a", BackupLastFullRun: run}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := `{"fqdn":"a","create_machine":"false","backup_last_full_run":` + c.j + `}`
+		if string(buf) != expected {
+			t.Log("Got     :", string(buf))
+			t.Log("Expected:", expected)
+			t.Fail()
+		}
+
+		var ms []Machine
+		err = json.Unmarshal([]byte("["+string(buf)+"]"), c.w.JSON(&ms))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ms) != 1 || !ms[0].BackupLastFullRun.Equal(run) || ms[0].BackupLastFullRun.Location() != c.w.location() {
+			t.Logf("%v: decoded %+v, expected %v", c.j, ms, run.In(c.w.location()))
+			t.Fail()
+		}
+	}
+
+	// server timestamps with zone keep their instant, zoneless ones are in the wire location
+	var m Machine
+	err = json.Unmarshal([]byte(`{"fqdn":"a","created_at":"2017-07-01T10:00:00.0Z","updated_at":"2017-07-01 12:00:00"}`), WireTime{Location: berlin}.JSON(&m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.CreatedAt.Equal(run) || !m.UpdatedAt.Equal(run) {
+		t.Log("unexpected server timestamps", m.CreatedAt, m.UpdatedAt)
+		t.Fail()
+	}
+
+	// the settings of one value don't affect others
+	buf, err := json.Marshal(Machine{Fqdn: "a", BackupLastFullRun: run})
+	if err != nil || string(buf) != `{"fqdn":"a","create_machine":"false","backup_last_full_run":"2017-07-01 10:00:00"}` {
+		t.Log("default wire time:", string(buf), err)
+		t.Fail()
+	}
+}
+
+func TestLegacySeconds(t *testing.T) {
+	run := time.Date(2017, 7, 1, 10, 0, 0, 999999999, time.UTC)
+
+	buf, err := json.Marshal(Machine{Fqdn: "a", BackupLastFullRun: run})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != `{"fqdn":"a","create_machine":"false","backup_last_full_run":"2017-07-01 10:00:00"}` {
+		t.Log("fractional seconds written:", string(buf))
+		t.Fail()
+	}
+
+	buf, err = json.Marshal(WireTime{Format: TimeFormatRFC3339}.JSON(&Machine{Fqdn: "a", BackupLastFullRun: run}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != `{"fqdn":"a","create_machine":"false","backup_last_full_run":"2017-07-01T10:00:00.999999999Z"}` {
+		t.Log("fractional seconds missing:", string(buf))
+		t.Fail()
+	}
+}
+
+func TestRoundTripRFC3339(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// with offsets, times during daylight saving time changes survive the round trip
+	w := WireTime{berlin, TimeFormatRFC3339}
+
+	f := func(rm randomMachine) bool {
+		buf, err := json.Marshal(w.JSON(&rm.m))
+		if err != nil {
+			return false
+		}
+
+		var m Machine
+		return json.Unmarshal(buf, w.JSON(&m)) == nil && Equal(&m, &rm.m)
+	}
+
+	err = quick.Check(f, &quick.Config{MaxCount: 1000})
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,45 @@
+package idbclient
+
+import "time"
+
+// RequestInfo describes a finished request to the IDB.
+type RequestInfo struct {
+	// HTTP method and URL path of the request. The query, containing the API token, is omitted.
+	Method string
+	Path   string
+
+	// HTTP status of the response, 0 if no response was received.
+	Status int
+
+	Start    time.Time
+	Duration time.Duration
+
+	// Number of retries before the final attempt. Idb doesn't retry requests yet, so this is always 0.
+	Retries int
+
+	// Transport error, nil if a response was received. Unexpected statuses are not errors here.
+	Err error
+}
+
+// Observer is notified about finished requests, e.g. to record metrics or tracing spans.
+// ObserveRequest must be safe for concurrent use.
+type Observer interface {
+	ObserveRequest(info RequestInfo)
+}
+
+// ObserverFunc adapts a function to the Observer interface.
+type ObserverFunc func(info RequestInfo)
+
+// ObserveRequest calls f(info).
+func (f ObserverFunc) ObserveRequest(info RequestInfo) {
+	f(info)
+}
+
+// Observers combines multiple observers into one, which notifies them in order.
+func Observers(observers ...Observer) Observer {
+	return ObserverFunc(func(info RequestInfo) {
+		for _, o := range observers {
+			o.ObserveRequest(info)
+		}
+	})
+}
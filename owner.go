@@ -0,0 +1,162 @@
+package idbclient
+
+import (
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/idb-project/idbclient/machine"
+	"github.com/idb-project/idbclient/owner"
+)
+
+// ListOwners retrieves all owners.
+func (i *Idb) ListOwners() ([]owner.Owner, error) {
+	var owners []owner.Owner
+	err := i.getJSON(i.joinBaseURL("owners"), &owners)
+	return owners, err
+}
+
+func (i *Idb) getOwner(key, value string) (*owner.Owner, error) {
+	u := i.joinBaseURL("owners")
+
+	query := url.Values{}
+	query.Add(key, value)
+	u.RawQuery = query.Encode()
+
+	var o owner.Owner
+	err := i.getJSON(u, &o)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// GetOwner retrieves a single owner identified by id.
+func (i *Idb) GetOwner(id int) (*owner.Owner, error) {
+	return i.getOwner("id", strconv.Itoa(id))
+}
+
+// GetOwnerByName retrieves a single owner identified by its name.
+func (i *Idb) GetOwnerByName(name string) (*owner.Owner, error) {
+	return i.getOwner("name", name)
+}
+
+// OwnerMachines retrieves the machines of the owner identified by id.
+func (i *Idb) OwnerMachines(id int) ([]machine.Machine, error) {
+	u := i.joinBaseURL("machines")
+
+	query := url.Values{}
+	query.Add("owner_id", strconv.Itoa(id))
+	u.RawQuery = query.Encode()
+
+	var machines []machine.Machine
+	err := i.getJSON(u, &machines)
+	return machines, err
+}
+
+// OwnerResolver resolves machine owner IDs to owners, caching all owners it has seen.
+// An OwnerResolver is safe for concurrent use.
+type OwnerResolver struct {
+	idb *Idb
+
+	// NegativeTTL is how long unknown owner IDs are cached. Zero disables caching them.
+	NegativeTTL time.Duration
+
+	mu     sync.Mutex
+	owners map[int]*owner.Owner
+
+	// unknown maps unknown owner IDs to the expiry of their entries.
+	unknown map[int]time.Time
+}
+
+// NewOwnerResolver creates an OwnerResolver using idb, caching unknown owner IDs for a minute.
+func NewOwnerResolver(idb *Idb) *OwnerResolver {
+	return &OwnerResolver{
+		idb:         idb,
+		NegativeTTL: time.Minute,
+		owners:      make(map[int]*owner.Owner),
+		unknown:     make(map[int]time.Time),
+	}
+}
+
+// Resolve returns the owners of machines, keyed by owner ID. Machines without owner are skipped,
+// unknown owner IDs are missing in the result. Owners not cached yet are fetched with a single
+// request for one owner, or by listing all owners otherwise. Lookups of other callers are not
+// blocked while fetching.
+func (r *OwnerResolver) Resolve(machines []machine.Machine) (map[int]*owner.Owner, error) {
+	r.mu.Lock()
+	now := time.Now()
+	missing := make(map[int]bool)
+	for _, m := range machines {
+		if m.OwnerID == 0 || r.owners[m.OwnerID] != nil {
+			continue
+		}
+		if expires, ok := r.unknown[m.OwnerID]; ok && now.Before(expires) {
+			continue
+		}
+		missing[m.OwnerID] = true
+	}
+	r.mu.Unlock()
+
+	var fetched []owner.Owner
+	var err error
+	switch len(missing) {
+	case 0:
+	case 1:
+		for id := range missing {
+			fetched, err = r.fetch(id)
+		}
+	default:
+		fetched, err = r.idb.ListOwners()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range fetched {
+		r.owners[fetched[i].ID] = &fetched[i]
+		delete(r.unknown, fetched[i].ID)
+	}
+	if r.NegativeTTL > 0 {
+		expires := time.Now().Add(r.NegativeTTL)
+		for id := range missing {
+			if r.owners[id] == nil {
+				r.unknown[id] = expires
+			}
+		}
+	}
+
+	result := make(map[int]*owner.Owner)
+	for _, m := range machines {
+		if o := r.owners[m.OwnerID]; o != nil {
+			result[m.OwnerID] = o
+		}
+	}
+
+	return result, nil
+}
+
+// Owner returns the owner of m, nil if m has no owner or the owner is unknown.
+func (r *OwnerResolver) Owner(m *machine.Machine) (*owner.Owner, error) {
+	owners, err := r.Resolve([]machine.Machine{*m})
+	if err != nil {
+		return nil, err
+	}
+	return owners[m.OwnerID], nil
+}
+
+// fetch retrieves the owner id. An unknown owner is no error, the result is empty then.
+func (r *OwnerResolver) fetch(id int) ([]owner.Owner, error) {
+	o, err := r.idb.GetOwner(id)
+	if notFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []owner.Owner{*o}, nil
+}
@@ -0,0 +1,38 @@
+// Package owner contains the IDB owner model. Owners are the customers or teams responsible for
+// machines, referenced by machine.Machine.OwnerID.
+package owner
+
+import "fmt"
+
+// Owner represents a IDB owner entry.
+type Owner struct {
+	// ID referenced by machines.
+	ID int `json:"id"`
+
+	// Full name, e.g. the company name.
+	Name string `json:"name"`
+
+	// Short name.
+	Nickname string `json:"nickname,omitempty"`
+
+	// Customer number in the accounting system.
+	CustomerID string `json:"customer_id,omitempty"`
+
+	// Textual description of the owner.
+	Description string `json:"description,omitempty"`
+
+	// Wiki page and repositories with documentation of the owner.
+	WikiURL string `json:"wikiurl,omitempty"`
+	Repos   string `json:"repos,omitempty"`
+
+	// Contact for announcements, e.g. maintenance windows.
+	AnnouncementContact string `json:"announcement_contact,omitempty"`
+}
+
+// String returns the name and, if set, the nickname of the owner.
+func (o *Owner) String() string {
+	if o.Nickname != "" && o.Nickname != o.Name {
+		return fmt.Sprintf("%v (%v)", o.Name, o.Nickname)
+	}
+	return o.Name
+}
@@ -0,0 +1,189 @@
+package idbclient_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/idb-project/idbclient"
+	"github.com/idb-project/idbclient/idbtest"
+	"github.com/idb-project/idbclient/machine"
+	"github.com/idb-project/idbclient/owner"
+)
+
+func ownerServer() *idbtest.Server {
+	s := idbtest.NewServer("secret")
+	s.PutOwners(
+		owner.Owner{ID: 1, Name: "Example Ltd.", Nickname: "example"},
+		owner.Owner{ID: 2, Name: "Other Inc."},
+		owner.Owner{ID: 3, Name: "Unused"},
+	)
+	s.Put(
+		machine.Machine{Fqdn: "a.example.com", OwnerID: 1},
+		machine.Machine{Fqdn: "b.example.com", OwnerID: 1},
+		machine.Machine{Fqdn: "c.example.com", OwnerID: 2},
+		machine.Machine{Fqdn: "d.example.com"},
+		machine.Machine{Fqdn: "e.example.com", OwnerID: 9},
+	)
+	return s
+}
+
+func TestOwners(t *testing.T) {
+	s := ownerServer()
+	defer s.Close()
+	idb := s.Idb()
+
+	owners, err := idb.ListOwners()
+	if err != nil || len(owners) != 3 {
+		t.Log("list:", owners, err)
+		t.Fail()
+	}
+
+	o, err := idb.GetOwner(1)
+	if err != nil || o.Name != "Example Ltd." || o.String() != "Example Ltd. (example)" {
+		t.Log("get by id:", o, err)
+		t.Fail()
+	}
+
+	o, err = idb.GetOwnerByName("Other Inc.")
+	if err != nil || o.ID != 2 {
+		t.Log("get by name:", o, err)
+		t.Fail()
+	}
+
+	_, err = idb.GetOwner(9)
+	if status(err) != http.StatusNotFound {
+		t.Log("get unknown owner:", err)
+		t.Fail()
+	}
+
+	machines, err := idb.OwnerMachines(1)
+	if err != nil || len(machines) != 2 || machines[0].Fqdn != "a.example.com" {
+		t.Log("machines of owner:", machines, err)
+		t.Fail()
+	}
+
+	machines, err = idb.OwnerMachines(3)
+	if err != nil || len(machines) != 0 {
+		t.Log("machines of owner without machines:", machines, err)
+		t.Fail()
+	}
+}
+
+func TestOwnerResolver(t *testing.T) {
+	s := ownerServer()
+	defer s.Close()
+	r := idbclient.NewOwnerResolver(s.Idb())
+
+	machines := s.Machines()
+
+	owners, err := r.Resolve(machines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(owners) != 2 || owners[1].Name != "Example Ltd." || owners[2].Name != "Other Inc." {
+		t.Log("resolved:", owners)
+		t.Fail()
+	}
+	if _, ok := owners[9]; ok {
+		t.Log("unknown owner resolved")
+		t.Fail()
+	}
+
+	// all owners are cached, including unknown IDs
+	n := s.Requests()
+	for i := range machines {
+		_, err := r.Owner(&machines[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.Requests() != n {
+		t.Logf("%v requests for cached owners", s.Requests()-n)
+		t.Fail()
+	}
+
+	// a single missing owner is fetched by ID, unknown IDs are not an error
+	r = idbclient.NewOwnerResolver(s.Idb())
+	o, err := r.Owner(&machine.Machine{Fqdn: "x.example.com", OwnerID: 2})
+	if err != nil || o == nil || o.ID != 2 {
+		t.Log("single owner:", o, err)
+		t.Fail()
+	}
+	o, err = r.Owner(&machine.Machine{Fqdn: "y.example.com", OwnerID: 9})
+	if err != nil || o != nil {
+		t.Log("unknown owner:", o, err)
+		t.Fail()
+	}
+
+	// failures are not cached
+	r = idbclient.NewOwnerResolver(s.Idb())
+	s.InjectFault(idbtest.Fault{Status: http.StatusServiceUnavailable, Count: 1})
+	_, err = r.Resolve(machines)
+	if status(err) != http.StatusServiceUnavailable {
+		t.Log("expected failure:", err)
+		t.Fail()
+	}
+	owners, err = r.Resolve(machines)
+	if err != nil || len(owners) != 2 {
+		t.Log("after failure:", owners, err)
+		t.Fail()
+	}
+}
+
+func TestOwnerResolverNegativeTTL(t *testing.T) {
+	s := ownerServer()
+	defer s.Close()
+	r := idbclient.NewOwnerResolver(s.Idb())
+	r.NegativeTTL = 50 * time.Millisecond
+
+	unknown := &machine.Machine{Fqdn: "e.example.com", OwnerID: 9}
+	r.Owner(unknown)
+
+	n := s.Requests()
+	r.Owner(unknown)
+	if s.Requests() != n {
+		t.Log("unknown owner requested again before NegativeTTL")
+		t.Fail()
+	}
+
+	// the owner is created later
+	s.PutOwners(owner.Owner{ID: 9, Name: "New Corp."})
+	time.Sleep(2 * r.NegativeTTL)
+
+	o, err := r.Owner(unknown)
+	if err != nil || o == nil || o.Name != "New Corp." {
+		t.Log("owner after NegativeTTL:", o, err)
+		t.Fail()
+	}
+}
+
+func TestOwnerResolverConcurrent(t *testing.T) {
+	s := ownerServer()
+	defer s.Close()
+	r := idbclient.NewOwnerResolver(s.Idb())
+
+	cached := &machine.Machine{Fqdn: "a.example.com", OwnerID: 1}
+	if _, err := r.Owner(cached); err != nil {
+		t.Fatal(err)
+	}
+
+	// a slow fetch doesn't block lookups of cached owners
+	s.InjectFault(idbtest.Fault{Latency: time.Second, Count: 1})
+	done := make(chan struct{})
+	go func() {
+		r.Owner(&machine.Machine{Fqdn: "c.example.com", OwnerID: 2})
+		close(done)
+	}()
+	for n := s.Requests(); s.Requests() == n; {
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	o, err := r.Owner(cached)
+	if err != nil || o == nil || time.Since(start) > 500*time.Millisecond {
+		t.Logf("lookup of cached owner during fetch: %v %v after %v", o, err, time.Since(start))
+		t.Fail()
+	}
+	<-done
+}
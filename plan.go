@@ -0,0 +1,110 @@
+package idbclient
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+// ChangeReport describes what a mutating call would change in the IDB.
+type ChangeReport struct {
+	Fqdn string
+
+	// The machine exists in the IDB.
+	Exists bool
+
+	// The machine exists, but is soft-deleted, i.e. deleted_at is set. Updates don't change that.
+	Deleted bool
+
+	// The machine doesn't exist and would be created.
+	Create bool
+
+	// The machine would be deleted.
+	Delete bool
+
+	// Changed fields. For machines which would be created, all fields of the update are listed.
+	Changes []machine.Change
+}
+
+// Noop reports whether the call wouldn't change anything. This is also the case for updates of
+// machines which don't exist if create is false, as the IDB rejects those.
+func (r *ChangeReport) Noop() bool {
+	if r.Delete {
+		return false
+	}
+	if !r.Exists && !r.Create {
+		return true
+	}
+	return len(r.Changes) == 0
+}
+
+func (r *ChangeReport) String() string {
+	var b bytes.Buffer
+
+	switch {
+	case r.Delete:
+		fmt.Fprintf(&b, "%v: delete", r.Fqdn)
+	case r.Create:
+		fmt.Fprintf(&b, "%v: create", r.Fqdn)
+	case !r.Exists:
+		fmt.Fprintf(&b, "%v: not found", r.Fqdn)
+		return b.String()
+	case len(r.Changes) == 0:
+		fmt.Fprintf(&b, "%v: unchanged", r.Fqdn)
+	default:
+		fmt.Fprintf(&b, "%v: update", r.Fqdn)
+	}
+
+	for _, c := range r.Changes {
+		fmt.Fprintf(&b, "\n  %v: %v -> %v", c.Field, c.Old, c.New)
+	}
+
+	return b.String()
+}
+
+// current fetches the machine identified by fqdn. If it doesn't exist, nil is returned without error.
+func (i *Idb) current(fqdn string) (*machine.Machine, error) {
+	m, err := i.GetMachine(fqdn)
+	if es, ok := err.(*ErrStatus); ok && es.Status() == http.StatusNotFound {
+		return nil, nil
+	}
+	return m, err
+}
+
+// PlanUpdate is the dry-run variant of UpdateMachine. It fetches the current machine and
+// reports the changes UpdateMachine would make, without modifying the IDB.
+func (i *Idb) PlanUpdate(m *machine.Machine, create bool) (*ChangeReport, error) {
+	current, err := i.current(m.Fqdn)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &ChangeReport{Fqdn: m.Fqdn, Exists: current != nil, Deleted: current != nil && !current.DeletedAt.IsZero()}
+	if current == nil {
+		if !create {
+			return r, nil
+		}
+
+		r.Create = true
+		current = new(machine.Machine)
+	}
+
+	r.Changes, err = machine.Diff(current, m)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// PlanDelete is the dry-run variant of DeleteMachine. It reports whether the machine exists and would be deleted.
+func (i *Idb) PlanDelete(fqdn string) (*ChangeReport, error) {
+	current, err := i.current(fqdn)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChangeReport{Fqdn: fqdn, Exists: current != nil, Delete: current != nil}, nil
+}
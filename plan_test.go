@@ -0,0 +1,136 @@
+package idbclient_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/idb-project/idbclient"
+	"github.com/idb-project/idbclient/idbtest"
+	"github.com/idb-project/idbclient/machine"
+)
+
+// methodRecorder records the methods of all requests sent through it.
+type methodRecorder struct {
+	transport http.RoundTripper
+
+	mu      sync.Mutex
+	methods []string
+}
+
+func (r *methodRecorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	r.methods = append(r.methods, request.Method)
+	r.mu.Unlock()
+	return r.transport.RoundTrip(request)
+}
+
+func (r *methodRecorder) mutating() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var mutating []string
+	for _, m := range r.methods {
+		if m != "GET" {
+			mutating = append(mutating, m)
+		}
+	}
+	return mutating
+}
+
+func planServer() (*idbtest.Server, *idbclient.Idb, *methodRecorder) {
+	s := idbtest.NewServer("secret")
+	s.Put(machine.Machine{Fqdn: "a.example.com", Cores: 2, Os: "Debian"})
+
+	idb := s.Idb()
+	recorder := &methodRecorder{transport: idb.Transport}
+	idb.Transport = recorder
+
+	return s, idb, recorder
+}
+
+func TestPlanUpdate(t *testing.T) {
+	s, idb, recorder := planServer()
+	defer s.Close()
+
+	tests := []struct {
+		m       machine.Machine
+		create  bool
+		exists  bool
+		changes []string
+		noop    bool
+	}{
+		{machine.Machine{Fqdn: "a.example.com", Cores: 4, Os: "Debian"}, false, true, []string{"cores"}, false},
+		{machine.Machine{Fqdn: "a.example.com", Cores: 2}, false, true, nil, true},
+		{machine.Machine{Fqdn: "b.example.com", Cores: 1, Os: "Debian"}, true, false, []string{"cores", "fqdn", "os"}, false},
+		{machine.Machine{Fqdn: "b.example.com", Cores: 1}, false, false, nil, true},
+	}
+
+	for _, v := range tests {
+		r, err := idb.PlanUpdate(&v.m, v.create)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var changes []string
+		for _, c := range r.Changes {
+			changes = append(changes, c.Field)
+		}
+
+		if r.Exists != v.exists || r.Create != (v.create && !v.exists) || r.Delete || r.Noop() != v.noop || len(changes) != len(v.changes) {
+			t.Logf("%+v create %v: %v", v.m, v.create, r)
+			t.Fail()
+			continue
+		}
+		for i := range changes {
+			if changes[i] != v.changes[i] {
+				t.Logf("%+v create %v: changes %v, expected %v", v.m, v.create, changes, v.changes)
+				t.Fail()
+				break
+			}
+		}
+	}
+
+	if m := recorder.mutating(); len(m) != 0 {
+		t.Log("dry run sent mutating requests:", m)
+		t.Fail()
+	}
+	if s.Machine("b.example.com") != nil || s.Machine("a.example.com").Cores != 2 {
+		t.Log("dry run modified the IDB")
+		t.Fail()
+	}
+}
+
+func TestPlanDelete(t *testing.T) {
+	s, idb, recorder := planServer()
+	defer s.Close()
+
+	r, err := idb.PlanDelete("a.example.com")
+	if err != nil || !r.Exists || !r.Delete || r.Noop() {
+		t.Log("delete of existing machine:", r, err)
+		t.Fail()
+	}
+
+	r, err = idb.PlanDelete("b.example.com")
+	if err != nil || r.Exists || r.Delete || !r.Noop() {
+		t.Log("delete of missing machine:", r, err)
+		t.Fail()
+	}
+
+	if m := recorder.mutating(); len(m) != 0 {
+		t.Log("dry run sent mutating requests:", m)
+		t.Fail()
+	}
+	if s.Machine("a.example.com") == nil {
+		t.Log("dry run deleted the machine")
+		t.Fail()
+	}
+
+	// errors other than 404 are returned
+	s.InjectFault(idbtest.Fault{Status: http.StatusInternalServerError, Count: 1})
+	_, err = idb.PlanDelete("a.example.com")
+	if status(err) != http.StatusInternalServerError {
+		t.Log("expected error:", err)
+		t.Fail()
+	}
+}
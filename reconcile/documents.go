@@ -0,0 +1,180 @@
+package reconcile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/idb-project/idbclient/machine"
+	"gopkg.in/yaml.v2"
+)
+
+// Decode decodes machines from JSON, or from YAML if ext is .yaml or .yml or buf doesn't look like JSON.
+// Documents contain a single machine or a list of machines, YAML files may contain multiple documents.
+// The JSON field names and formats of machine.Machine are used in both cases.
+func Decode(buf []byte, ext string) ([]machine.Machine, error) {
+	buf = bytes.TrimSpace(buf)
+
+	if ext != ".yaml" && ext != ".yml" && len(buf) > 0 && (buf[0] == '{' || buf[0] == '[') {
+		return decodeJSON(buf)
+	}
+
+	var machines []machine.Machine
+
+	dec := yaml.NewDecoder(bytes.NewReader(buf))
+	for {
+		var v interface{}
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+
+		v, err = jsonValue(v)
+		if err != nil {
+			return nil, err
+		}
+
+		doc, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		ms, err := decodeJSON(doc)
+		if err != nil {
+			return nil, err
+		}
+		machines = append(machines, ms...)
+	}
+
+	return machines, nil
+}
+
+func decodeJSON(buf []byte) ([]machine.Machine, error) {
+	if len(buf) > 0 && buf[0] == '[' {
+		var machines []machine.Machine
+		err := json.Unmarshal(buf, &machines)
+		return machines, err
+	}
+
+	var m machine.Machine
+	err := json.Unmarshal(buf, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	return []machine.Machine{m}, nil
+}
+
+// jsonValue converts the map types produced by the YAML decoder to types encodable as JSON.
+func jsonValue(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			ks, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("non-string key %v", k)
+			}
+
+			var err error
+			m[ks], err = jsonValue(e)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case []interface{}:
+		for i, e := range t {
+			var err error
+			t[i], err = jsonValue(e)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return t, nil
+	}
+
+	return v, nil
+}
+
+// ReadFile reads the machines of a JSON or YAML file. The path "-" reads from standard input.
+func ReadFile(path string) ([]machine.Machine, error) {
+	var buf []byte
+	var err error
+
+	if path == "-" {
+		buf, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		buf, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	machines, err := Decode(buf, filepath.Ext(path))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+
+	return machines, nil
+}
+
+// ReadDir reads the machines of all .yaml, .yml and .json files below dir, in lexical order.
+// A machine defined more than once is an error.
+func ReadDir(dir string) ([]machine.Machine, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		switch filepath.Ext(path) {
+		case ".yaml", ".yml", ".json":
+			if info.Mode().IsRegular() {
+				files = append(files, path)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	var machines []machine.Machine
+	defined := make(map[string]string)
+
+	for _, f := range files {
+		ms, err := ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range ms {
+			if m.Fqdn == "" {
+				return nil, fmt.Errorf("%v: machine without fqdn", f)
+			}
+			if first, ok := defined[m.Fqdn]; ok {
+				return nil, fmt.Errorf("%v: %v already defined in %v", f, m.Fqdn, first)
+			}
+			defined[m.Fqdn] = f
+		}
+
+		machines = append(machines, ms...)
+	}
+
+	return machines, nil
+}
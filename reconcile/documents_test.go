@@ -0,0 +1,43 @@
+package reconcile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+var testTime = time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+
+var decodeTests = []struct {
+	doc      string
+	ext      string
+	machines []machine.Machine
+}{
+	{`{"fqdn":"a","cores":2}`, ".json", []machine.Machine{{Fqdn: "a", Cores: 2}}},
+	{`[{"fqdn":"a"},{"fqdn":"b"}]`, "", []machine.Machine{{Fqdn: "a"}, {Fqdn: "b"}}},
+	{"fqdn: a\nbackup_last_full_run: 2006-01-02 15:04:05\nnics:\n- name: lo\n  ip_address:\n    addr: 127.0.0.1\n---\n- fqdn: b\n  auto_update: true\n", ".yaml",
+		[]machine.Machine{
+			{Fqdn: "a", BackupLastFullRun: testTime, Nics: []machine.Nic{{Name: "lo", IPAddress: machine.IPAddress{Addr: "127.0.0.1"}}}},
+			{Fqdn: "b", AutoUpdate: true},
+		}},
+}
+
+func TestDecode(t *testing.T) {
+	for _, v := range decodeTests {
+		machines, err := Decode([]byte(v.doc), v.ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(machines) != len(v.machines) {
+			t.Fatalf("Got %+v, expected %+v", machines, v.machines)
+		}
+
+		for i := range machines {
+			if !machine.Equal(&machines[i], &v.machines[i]) {
+				t.Errorf("Got %+v, expected %+v", machines[i], v.machines[i])
+			}
+		}
+	}
+}
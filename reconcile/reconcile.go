@@ -0,0 +1,203 @@
+// Package reconcile applies desired machine states, e.g. kept as YAML files in version control, to the IDB.
+//
+// Desired documents only need to contain the fields to manage. Fields with zero values are not managed,
+// as updates omit them.
+package reconcile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/idb-project/idbclient"
+	"github.com/idb-project/idbclient/machine"
+)
+
+// ErrEmptyPrune is returned if pruning is requested with an empty desired set, which would delete all machines.
+var ErrEmptyPrune = errors.New("reconcile: refusing to prune with an empty desired set")
+
+// Step is a single planned change.
+type Step struct {
+	Report *idbclient.ChangeReport
+
+	// Desired state, nil for deletions.
+	Desired *machine.Machine
+
+	// The desired machine is soft-deleted in the IDB and is restored.
+	Undelete bool
+}
+
+// Noop reports whether the step doesn't change anything.
+func (s Step) Noop() bool {
+	return !s.Undelete && s.Report.Noop()
+}
+
+func (s Step) String() string {
+	if !s.Undelete {
+		return s.Report.String()
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%v: undelete", s.Report.Fqdn)
+	for _, c := range s.Report.Changes {
+		fmt.Fprintf(&b, "\n  %v: %v -> %v", c.Field, c.Old, c.New)
+	}
+	return b.String()
+}
+
+// Plan contains the steps to reconcile the IDB with the desired machines.
+type Plan struct {
+	Steps []Step
+}
+
+// MakePlan compares the desired machines with the IDB. Machines not existing in the IDB are created,
+// soft-deleted ones are restored. If prune is set, machines in the IDB which are not desired are
+// soft-deleted, see Apply.
+func MakePlan(idb *idbclient.Idb, desired []machine.Machine, prune bool) (*Plan, error) {
+	if prune && len(desired) == 0 {
+		return nil, ErrEmptyPrune
+	}
+
+	p := new(Plan)
+	names := make(map[string]bool)
+
+	for i := range desired {
+		r, err := idb.PlanUpdate(&desired[i], true)
+		if err != nil {
+			return nil, err
+		}
+
+		names[desired[i].Fqdn] = true
+		p.Steps = append(p.Steps, Step{r, &desired[i], r.Deleted})
+	}
+
+	if !prune {
+		return p, nil
+	}
+
+	current, err := idb.ListMachines()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range current {
+		// already deleted machines
+		if !m.DeletedAt.IsZero() || names[m.Fqdn] {
+			continue
+		}
+
+		p.Steps = append(p.Steps, Step{Report: &idbclient.ChangeReport{Fqdn: m.Fqdn, Exists: true, Delete: true}})
+	}
+
+	return p, nil
+}
+
+// Summary counts the steps of a plan by kind. After Apply, it counts the performed steps.
+type Summary struct {
+	Create    int
+	Update    int
+	Delete    int
+	Undelete  int
+	Unchanged int
+	Failed    int
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("create: %v, update: %v, delete: %v, undelete: %v, unchanged: %v, failed: %v", s.Create, s.Update, s.Delete, s.Undelete, s.Unchanged, s.Failed)
+}
+
+func (s *Summary) count(step Step) {
+	r := step.Report
+	switch {
+	case step.Undelete:
+		s.Undelete++
+	case r.Delete:
+		s.Delete++
+	case r.Create:
+		s.Create++
+	case r.Noop():
+		s.Unchanged++
+	default:
+		s.Update++
+	}
+}
+
+// Summary counts the steps of p.
+func (p *Plan) Summary() Summary {
+	var s Summary
+	for _, step := range p.Steps {
+		s.count(step)
+	}
+	return s
+}
+
+// StepError is the error of a failed step.
+type StepError struct {
+	Fqdn string
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Fqdn, e.Err)
+}
+
+// Apply performs the steps of p. New machines are created with all desired fields, existing machines
+// are only sent the changed fields. Pruned machines are soft-deleted by setting deleted_at, they stay
+// in the IDB. Restored machines get deleted_at cleared before the changed fields are sent. Failed steps
+// don't stop the application and are returned as errors.
+func (p *Plan) Apply(idb *idbclient.Idb) (Summary, []*StepError) {
+	var s Summary
+	var errs []*StepError
+
+	now := time.Now()
+
+	for _, step := range p.Steps {
+		r := step.Report
+		if step.Noop() {
+			s.count(step)
+			continue
+		}
+
+		var err error
+		switch {
+		case r.Delete:
+			_, err = idb.UpdateMachine(&machine.Machine{Fqdn: r.Fqdn, DeletedAt: now}, false)
+		case r.Create:
+			_, err = idb.UpdateMachine(step.Desired, true)
+		default:
+			if step.Undelete {
+				_, err = idb.UndeleteMachine(r.Fqdn)
+			}
+			if err == nil && len(r.Changes) > 0 {
+				err = update(idb, step)
+			}
+		}
+
+		if err != nil {
+			s.Failed++
+			errs = append(errs, &StepError{r.Fqdn, err})
+			continue
+		}
+
+		s.count(step)
+	}
+
+	return s, errs
+}
+
+// update sends the changed fields of step.
+func update(idb *idbclient.Idb, step Step) error {
+	fields := make([]string, len(step.Report.Changes))
+	for i, c := range step.Report.Changes {
+		fields[i] = c.Field
+	}
+
+	m, err := machine.Select(step.Desired, fields...)
+	if err != nil {
+		return err
+	}
+
+	_, err = idb.UpdateMachine(m, false)
+	return err
+}
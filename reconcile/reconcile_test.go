@@ -0,0 +1,192 @@
+package reconcile
+
+import (
+	"testing"
+
+	"github.com/idb-project/idbclient/idbtest"
+	"github.com/idb-project/idbclient/machine"
+)
+
+func TestApply(t *testing.T) {
+	s := idbtest.NewServer("secret")
+	defer s.Close()
+	s.Put(
+		machine.Machine{Fqdn: "update.example.com", Cores: 2, Os: "Debian"},
+		machine.Machine{Fqdn: "same.example.com", Cores: 4},
+		machine.Machine{Fqdn: "prune.example.com", Cores: 1},
+		machine.Machine{Fqdn: "deleted.example.com", DeletedAt: testTime},
+	)
+	idb := s.Idb()
+
+	desired := []machine.Machine{
+		{Fqdn: "update.example.com", Cores: 8},
+		{Fqdn: "same.example.com", Cores: 4},
+		{Fqdn: "create.example.com", Cores: 1, RAM: 1024},
+	}
+
+	p, err := MakePlan(idb, desired, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Summary{Create: 1, Update: 1, Delete: 1, Unchanged: 1}
+	if p.Summary() != expected {
+		t.Logf("plan: %v, expected %v", p.Summary(), expected)
+		t.Fail()
+	}
+
+	// planning doesn't modify the IDB
+	if s.Machine("create.example.com") != nil || s.Machine("update.example.com").Cores != 2 {
+		t.Log("plan modified the IDB")
+		t.Fail()
+	}
+
+	summary, errs := p.Apply(idb)
+	if len(errs) != 0 || summary != expected {
+		t.Logf("apply: %v %v, expected %v", summary, errs, expected)
+		t.Fail()
+	}
+
+	if m := s.Machine("update.example.com"); m.Cores != 8 || m.Os != "Debian" {
+		t.Logf("updated machine: %+v", m)
+		t.Fail()
+	}
+	if m := s.Machine("create.example.com"); m == nil || m.RAM != 1024 {
+		t.Logf("created machine: %+v", m)
+		t.Fail()
+	}
+	if m := s.Machine("prune.example.com"); m == nil || m.DeletedAt.IsZero() || m.Cores != 1 {
+		t.Logf("pruned machine not soft-deleted: %+v", m)
+		t.Fail()
+	}
+	if m := s.Machine("deleted.example.com"); !m.DeletedAt.Equal(testTime) {
+		t.Logf("already deleted machine modified: %+v", m)
+		t.Fail()
+	}
+
+	// a second run has nothing to do
+	p, err = MakePlan(idb, desired, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Summary() != (Summary{Unchanged: 3}) {
+		t.Logf("second plan: %v", p.Summary())
+		t.Fail()
+	}
+}
+
+func TestUndelete(t *testing.T) {
+	s := idbtest.NewServer("secret")
+	defer s.Close()
+	s.Put(
+		machine.Machine{Fqdn: "a.example.com", Cores: 1},
+		machine.Machine{Fqdn: "b.example.com", Cores: 1},
+		machine.Machine{Fqdn: "c.example.com", Cores: 1},
+	)
+	idb := s.Idb()
+
+	p, err := MakePlan(idb, []machine.Machine{{Fqdn: "a.example.com", Cores: 1}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, errs := p.Apply(idb); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	// b is added back unchanged, c with a changed field
+	desired := []machine.Machine{
+		{Fqdn: "a.example.com", Cores: 1},
+		{Fqdn: "b.example.com", Cores: 1},
+		{Fqdn: "c.example.com", Cores: 2},
+	}
+	p, err = MakePlan(idb, desired, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Summary{Undelete: 2, Unchanged: 1}
+	if p.Summary() != expected {
+		t.Logf("plan: %v, expected %v", p.Summary(), expected)
+		t.Fail()
+	}
+	if p.Steps[1].String() != "b.example.com: undelete" {
+		t.Logf("step: %q", p.Steps[1])
+		t.Fail()
+	}
+
+	summary, errs := p.Apply(idb)
+	if len(errs) != 0 || summary != expected {
+		t.Logf("apply: %v %v, expected %v", summary, errs, expected)
+		t.Fail()
+	}
+
+	if m := s.Machine("b.example.com"); !m.DeletedAt.IsZero() {
+		t.Logf("machine not restored: %+v", m)
+		t.Fail()
+	}
+	if m := s.Machine("c.example.com"); !m.DeletedAt.IsZero() || m.Cores != 2 {
+		t.Logf("machine not restored and updated: %+v", m)
+		t.Fail()
+	}
+
+	p, err = MakePlan(idb, desired, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Summary() != (Summary{Unchanged: 3}) {
+		t.Logf("plan after restoring: %v", p.Summary())
+		t.Fail()
+	}
+}
+
+func TestApplyWithoutPrune(t *testing.T) {
+	s := idbtest.NewServer("secret")
+	defer s.Close()
+	s.Put(machine.Machine{Fqdn: "other.example.com"})
+
+	p, err := MakePlan(s.Idb(), []machine.Machine{{Fqdn: "a.example.com", Cores: 1}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Summary() != (Summary{Create: 1}) {
+		t.Logf("plan: %v", p.Summary())
+		t.Fail()
+	}
+}
+
+func TestApplyFailure(t *testing.T) {
+	s := idbtest.NewServer("secret")
+	defer s.Close()
+	idb := s.Idb()
+
+	p, err := MakePlan(idb, []machine.Machine{{Fqdn: "a.example.com", Cores: 1}, {Fqdn: "b.example.com", Cores: 1}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.InjectFault(idbtest.Fault{Method: "PUT", Status: 500, Count: 1})
+	summary, errs := p.Apply(idb)
+	if summary != (Summary{Create: 1, Failed: 1}) || len(errs) != 1 || errs[0].Fqdn != "a.example.com" {
+		t.Logf("apply with failure: %v %v", summary, errs)
+		t.Fail()
+	}
+}
+
+func TestEmptyPrune(t *testing.T) {
+	s := idbtest.NewServer("secret")
+	defer s.Close()
+	s.Put(machine.Machine{Fqdn: "a.example.com"})
+
+	_, err := MakePlan(s.Idb(), nil, true)
+	if err != ErrEmptyPrune {
+		t.Log("empty prune:", err)
+		t.Fail()
+	}
+
+	n := s.Requests()
+	_, err = MakePlan(s.Idb(), []machine.Machine{}, true)
+	if err != ErrEmptyPrune || s.Requests() != n {
+		t.Log("empty prune sent requests:", err)
+		t.Fail()
+	}
+}
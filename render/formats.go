@@ -0,0 +1,199 @@
+package render
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/idb-project/idbclient/machine"
+	"gopkg.in/yaml.v2"
+)
+
+// rows returns the text values of columns for all machines.
+func rows(machines []machine.Machine, columns []string) ([][]string, error) {
+	err := CheckColumns(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, len(machines))
+	for i := range machines {
+		r, err := newRecord(&machines[i])
+		if err != nil {
+			return nil, err
+		}
+
+		rows[i] = make([]string, len(columns))
+		for j, c := range columns {
+			rows[i][j], err = r.text(c)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return rows, nil
+}
+
+// Table writes machines as table with aligned columns and a header line.
+func Table(w io.Writer, machines []machine.Machine, columns []string) error {
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+
+	rows, err := rows(machines, columns)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = strings.ToUpper(c)
+	}
+
+	_, err = io.WriteString(tw, strings.Join(header, "\t")+"\n")
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		// tabs and newlines in values would break the alignment
+		for i := range row {
+			row[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(row[i])
+		}
+
+		_, err = io.WriteString(tw, strings.Join(row, "\t")+"\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+// CSV writes machines as RFC 4180 CSV with a header line.
+func CSV(w io.Writer, machines []machine.Machine, columns []string) error {
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+
+	rows, err := rows(machines, columns)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+
+	err = cw.Write(columns)
+	if err != nil {
+		return err
+	}
+
+	err = cw.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
+
+// mapSlice returns the set columns of a machine in order.
+// If columns is empty, all set fields are returned without flattening the interfaces.
+func mapSlice(m *machine.Machine, columns []string) (yaml.MapSlice, error) {
+	r, err := newRecord(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var ms yaml.MapSlice
+
+	if len(columns) == 0 {
+		for _, c := range machine.FieldNames() {
+			if v, ok := r.fields[c]; ok {
+				ms = append(ms, yaml.MapItem{Key: c, Value: plain(v)})
+			}
+		}
+		return ms, nil
+	}
+
+	for _, c := range columns {
+		v, err := r.value(c)
+		if err != nil {
+			return nil, err
+		}
+
+		if v != nil {
+			ms = append(ms, yaml.MapItem{Key: c, Value: plain(v)})
+		}
+	}
+
+	return ms, nil
+}
+
+// YAML writes machines as a YAML sequence.
+func YAML(w io.Writer, machines []machine.Machine, columns []string) error {
+	err := CheckColumns(columns)
+	if err != nil {
+		return err
+	}
+
+	docs := make([]yaml.MapSlice, len(machines))
+	for i := range machines {
+		docs[i], err = mapSlice(&machines[i], columns)
+		if err != nil {
+			return err
+		}
+	}
+
+	buf, err := yaml.Marshal(docs)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
+
+// JSONLines writes every machine as JSON object on a separate line.
+// Complete machines are written in the same format as sent to the IDB, without create_machine.
+func JSONLines(w io.Writer, machines []machine.Machine, columns []string) error {
+	err := CheckColumns(columns)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	for i := range machines {
+		r, err := newRecord(&machines[i])
+		if err != nil {
+			return err
+		}
+
+		obj := r.fields
+		if len(columns) > 0 {
+			obj = make(map[string]interface{}, len(columns))
+			for _, c := range columns {
+				v, err := r.value(c)
+				if err != nil {
+					return err
+				}
+
+				if v != nil {
+					obj[c] = v
+				}
+			}
+		}
+
+		err = enc.Encode(obj)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
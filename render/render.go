@@ -0,0 +1,230 @@
+// Package render writes machines as tables, CSV, YAML or JSON Lines.
+//
+// Columns are the JSON field names of machine.Machine, e.g. "fqdn" or "pending_security_updates".
+// Network interfaces are flattened in two ways:
+//
+//	nics           all interfaces as "name=addr/netmask,addr_v6/netmask_v6", separated by "; "
+//	nic.N.FIELD    FIELD (name, addr, netmask, addr_v6 or netmask_v6) of the N-th interface, starting at 0
+//
+// Empty addresses are left out of the nics column. Unset fields render as empty strings in tables and CSV
+// and are left out of YAML and JSON Lines output.
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+// Format is an output format.
+type Format int
+
+const (
+	FormatTable Format = iota
+	FormatCSV
+	FormatYAML
+	FormatJSONLines
+)
+
+var formatNames = map[string]Format{
+	"table": FormatTable,
+	"csv":   FormatCSV,
+	"yaml":  FormatYAML,
+	"jsonl": FormatJSONLines,
+}
+
+// ParseFormat returns the format with the given name: table, csv, yaml or jsonl.
+func ParseFormat(name string) (Format, error) {
+	f, ok := formatNames[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("render: unknown format %q", name)
+	}
+	return f, nil
+}
+
+// DefaultColumns are used for tables and CSV if no columns are given.
+var DefaultColumns = []string{"fqdn", "os", "os_release", "arch", "cores", "ram", "diskspace"}
+
+var nicFields = []string{"name", "addr", "netmask", "addr_v6", "netmask_v6"}
+
+// ErrColumn is returned for unknown column names.
+type ErrColumn struct {
+	column string
+}
+
+func (e *ErrColumn) Error() string {
+	return fmt.Sprintf("render: unknown column %q", e.column)
+}
+
+// Columns returns the names of all columns, except the nic.N.FIELD columns.
+func Columns() []string {
+	return machine.FieldNames()
+}
+
+// CheckColumns returns an error if one of columns is unknown.
+func CheckColumns(columns []string) error {
+	for _, c := range columns {
+		if _, _, err := parseColumn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseColumn returns the interface index and field for nic.N.FIELD columns, or -1 for other valid columns.
+func parseColumn(column string) (int, string, error) {
+	if strings.HasPrefix(column, "nic.") {
+		parts := strings.Split(column, ".")
+		if len(parts) != 3 {
+			return 0, "", &ErrColumn{column}
+		}
+
+		n, err := strconv.Atoi(parts[1])
+		if err != nil || n < 0 {
+			return 0, "", &ErrColumn{column}
+		}
+
+		for _, f := range nicFields {
+			if f == parts[2] {
+				return n, f, nil
+			}
+		}
+
+		return 0, "", &ErrColumn{column}
+	}
+
+	for _, c := range Columns() {
+		if c == column {
+			return -1, "", nil
+		}
+	}
+
+	return 0, "", &ErrColumn{column}
+}
+
+func nicField(nic *machine.Nic, field string) string {
+	switch field {
+	case "name":
+		return nic.Name
+	case "addr":
+		return nic.IPAddress.Addr
+	case "netmask":
+		return nic.IPAddress.Netmask
+	case "addr_v6":
+		return nic.IPAddress.AddrV6
+	case "netmask_v6":
+		return nic.IPAddress.NetmaskV6
+	}
+	return ""
+}
+
+// flattenNics formats all interfaces of m for the nics column.
+func flattenNics(nics []machine.Nic) string {
+	entries := make([]string, len(nics))
+
+	for i, n := range nics {
+		var addrs []string
+		if n.IPAddress.Addr != "" {
+			addrs = append(addrs, n.IPAddress.Addr+"/"+n.IPAddress.Netmask)
+		}
+		if n.IPAddress.AddrV6 != "" {
+			addrs = append(addrs, n.IPAddress.AddrV6+"/"+n.IPAddress.NetmaskV6)
+		}
+		entries[i] = n.Name + "=" + strings.Join(addrs, ",")
+	}
+
+	return strings.Join(entries, "; ")
+}
+
+// record holds the column values of a machine.
+type record struct {
+	m      *machine.Machine
+	fields map[string]interface{}
+}
+
+func newRecord(m *machine.Machine) (*record, error) {
+	fields, err := machine.Fields(m)
+	if err != nil {
+		return nil, err
+	}
+	return &record{m, fields}, nil
+}
+
+// value returns the value of column, nil if unset. Numbers are returned as json.Number.
+func (r *record) value(column string) (interface{}, error) {
+	n, f, err := parseColumn(column)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case n >= 0:
+		if n >= len(r.m.Nics) {
+			return nil, nil
+		}
+		if v := nicField(&r.m.Nics[n], f); v != "" {
+			return v, nil
+		}
+		return nil, nil
+	case column == "nics":
+		if len(r.m.Nics) == 0 {
+			return nil, nil
+		}
+		return flattenNics(r.m.Nics), nil
+	}
+
+	return r.fields[column], nil
+}
+
+// text returns the value of column as string.
+func (r *record) text(column string) (string, error) {
+	v, err := r.value(column)
+	if err != nil || v == nil {
+		return "", err
+	}
+	return fmt.Sprint(v), nil
+}
+
+// plain converts json.Number values to int64 or float64, so they are encoded as numbers by the YAML encoder.
+func plain(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+		return t.String()
+	case []interface{}:
+		for i, e := range t {
+			t[i] = plain(e)
+		}
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = plain(e)
+		}
+	}
+	return v
+}
+
+// Write writes machines in format f. If columns is empty, DefaultColumns are used for tables and CSV
+// and complete machines are written for YAML and JSON Lines.
+func Write(w io.Writer, f Format, machines []machine.Machine, columns []string) error {
+	switch f {
+	case FormatTable:
+		return Table(w, machines, columns)
+	case FormatCSV:
+		return CSV(w, machines, columns)
+	case FormatYAML:
+		return YAML(w, machines, columns)
+	case FormatJSONLines:
+		return JSONLines(w, machines, columns)
+	}
+
+	return fmt.Errorf("render: unknown format %v", f)
+}
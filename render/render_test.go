@@ -0,0 +1,53 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+var testMachines = []machine.Machine{
+	{Fqdn: "a.example.org", Os: "Debian", Cores: 4, Nics: []machine.Nic{
+		{Name: "eth0", IPAddress: machine.IPAddress{Addr: "10.0.0.1", Netmask: "255.255.255.0", AddrV6: "2001:db8::1", NetmaskV6: "64"}},
+		{Name: "lo", IPAddress: machine.IPAddress{Addr: "127.0.0.1", Netmask: "255.0.0.0"}},
+	}},
+	{Fqdn: "b.example.org", Description: "says \"hi\", twice"},
+}
+
+var renderTests = []struct {
+	f       Format
+	columns []string
+	out     string
+}{
+	{FormatTable, []string{"fqdn", "cores", "nic.1.addr"}, "FQDN           CORES  NIC.1.ADDR\na.example.org  4      127.0.0.1\nb.example.org         \n"},
+	{FormatCSV, []string{"fqdn", "description", "nics"}, "fqdn,description,nics\r\na.example.org,,\"eth0=10.0.0.1/255.255.255.0,2001:db8::1/64; lo=127.0.0.1/255.0.0.0\"\r\nb.example.org,\"says \"\"hi\"\", twice\",\r\n"},
+	{FormatYAML, []string{"fqdn", "cores", "nic.0.name"}, "- fqdn: a.example.org\n  cores: 4\n  nic.0.name: eth0\n- fqdn: b.example.org\n"},
+	{FormatYAML, nil, "- fqdn: a.example.org\n  os: Debian\n  cores: 4\n  nics:\n  - ip_address:\n      addr: 10.0.0.1\n      addr_v6: 2001:db8::1\n      netmask: 255.255.255.0\n      netmask_v6: \"64\"\n    name: eth0\n  - ip_address:\n      addr: 127.0.0.1\n      netmask: 255.0.0.0\n    name: lo\n- fqdn: b.example.org\n  description: says \"hi\", twice\n"},
+	{FormatJSONLines, []string{"fqdn", "cores"}, "{\"cores\":4,\"fqdn\":\"a.example.org\"}\n{\"fqdn\":\"b.example.org\"}\n"},
+}
+
+func TestWrite(t *testing.T) {
+	for _, v := range renderTests {
+		var buf bytes.Buffer
+		err := Write(&buf, v.f, testMachines, v.columns)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if buf.String() != v.out {
+			t.Logf("Got     : %q", buf.String())
+			t.Logf("Expected: %q", v.out)
+			t.Fail()
+		}
+	}
+}
+
+func TestUnknownColumn(t *testing.T) {
+	for _, c := range []string{"unknown", "nic.x.addr", "nic.0.mac"} {
+		err := Write(&bytes.Buffer{}, FormatCSV, testMachines, []string{c})
+		if _, ok := err.(*ErrColumn); !ok {
+			t.Errorf("%v: got %v, expected ErrColumn", c, err)
+		}
+	}
+}
@@ -0,0 +1,435 @@
+// Package spool keeps machine updates on disk while the IDB is unreachable and replays them later.
+//
+// Every FQDN has at most one entry, a JSON file in the spool directory. Spooling an update for an FQDN
+// which already has an entry merges the fields, newer values replacing older ones. As updates don't send
+// zero values, a field left at its zero value by the newer update keeps the spooled value, just as if both
+// updates had been sent. Entries are written to a temporary file and renamed, so a crash never leaves a
+// partial entry behind. Entries which can't be read are moved aside with the prefix ".bad-" by Flush.
+//
+// Entries are replayed in the order they were first spooled. After a failed replay, an entry is retried
+// with exponential backoff. Entries not updated for MaxAge are dropped.
+package spool
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/idb-project/idbclient"
+	"github.com/idb-project/idbclient/machine"
+)
+
+const ext = ".json"
+
+// Default settings of spools created by Open.
+const (
+	DefaultMaxAge     = 7 * 24 * time.Hour
+	DefaultBackoff    = time.Minute
+	DefaultMaxBackoff = time.Hour
+)
+
+// Updater submits machine updates, e.g. *idbclient.Idb.
+type Updater interface {
+	UpdateMachine(m *machine.Machine, create bool) (*machine.Machine, error)
+}
+
+var _ Updater = (*idbclient.Idb)(nil)
+
+// ErrSpooled is returned by Update if the update failed temporarily and was kept in the spool.
+type ErrSpooled struct {
+	Fqdn string
+	Err  error
+}
+
+func (e *ErrSpooled) Error() string {
+	return fmt.Sprintf("spooled update of %v: %v", e.Fqdn, e.Err)
+}
+
+func (e *ErrSpooled) Unwrap() error {
+	return e.Err
+}
+
+// EntryError is the error of an entry which was rejected by the IDB and dropped.
+type EntryError struct {
+	Fqdn string
+	Err  error
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("dropped update of %v: %v", e.Fqdn, e.Err)
+}
+
+// entry is the file format of spooled updates.
+type entry struct {
+	// Time the FQDN was first spooled, defining the replay order.
+	Queued time.Time `json:"queued"`
+
+	// Time of the latest merged update, defining the age.
+	Updated time.Time `json:"updated"`
+
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+
+	Create bool                   `json:"create"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// age returns the time of the latest update of e. Entries without update time are aged by Queued.
+func (e *entry) age() time.Time {
+	if e.Updated.IsZero() {
+		return e.Queued
+	}
+	return e.Updated
+}
+
+// machine decodes the fields of e.
+func (e *entry) machine() (*machine.Machine, error) {
+	buf, err := json.Marshal(e.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	m := new(machine.Machine)
+	err = json.Unmarshal(buf, m)
+	return m, err
+}
+
+// Spool is a directory of spooled updates. A Spool is safe for concurrent use, but a directory must
+// not be used by multiple processes at the same time.
+type Spool struct {
+	dir string
+
+	// Entries not updated for MaxAge are dropped. Zero keeps entries forever.
+	MaxAge time.Duration
+
+	// Backoff after the first failed replay, doubled after each further failure up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	mu sync.Mutex
+
+	// now is replaced in tests.
+	now func() time.Time
+}
+
+// Open opens the spool in dir, creating the directory if necessary.
+func Open(dir string) (*Spool, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Spool{
+		dir:        dir,
+		MaxAge:     DefaultMaxAge,
+		Backoff:    DefaultBackoff,
+		MaxBackoff: DefaultMaxBackoff,
+		now:        time.Now,
+	}, nil
+}
+
+// Dir returns the spool directory.
+func (s *Spool) Dir() string {
+	return s.dir
+}
+
+func (s *Spool) path(fqdn string) string {
+	return filepath.Join(s.dir, url.PathEscape(fqdn)+ext)
+}
+
+func (s *Spool) read(path string) (*entry, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	e := new(entry)
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	err = dec.Decode(e)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return e, nil
+}
+
+// write replaces the entry for fqdn atomically.
+func (s *Spool) write(fqdn string, e *entry) error {
+	buf, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(buf)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path(fqdn))
+}
+
+// Add spools an update of m, merging it with an already spooled update of the same machine.
+// Fields with zero values in m don't replace spooled values.
+func (s *Spool) Add(m *machine.Machine, create bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.add(m, create)
+}
+
+func (s *Spool) add(m *machine.Machine, create bool) error {
+	if m.Fqdn == "" {
+		return errors.New("spool: machine without fqdn")
+	}
+
+	fields, err := machine.Fields(m)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+
+	e, err := s.read(s.path(m.Fqdn))
+	switch {
+	case os.IsNotExist(err):
+		e = &entry{Queued: now, Fields: fields}
+	case err != nil:
+		return err
+	default:
+		for k, v := range fields {
+			e.Fields[k] = v
+		}
+		// replay the merged update right away
+		e.NextAttempt = time.Time{}
+	}
+
+	e.Updated = now
+	e.Create = e.Create || create
+
+	return s.write(m.Fqdn, e)
+}
+
+// Len returns the number of spooled entries.
+func (s *Spool) Len() (int, error) {
+	names, err := s.names()
+	return len(names), err
+}
+
+// names returns the FQDNs of all entries.
+func (s *Spool) names() ([]string, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, f := range files {
+		n := f.Name()
+		if f.IsDir() || strings.HasPrefix(n, ".") || !strings.HasSuffix(n, ext) {
+			continue
+		}
+
+		fqdn, err := url.PathUnescape(strings.TrimSuffix(n, ext))
+		if err != nil {
+			continue
+		}
+		names = append(names, fqdn)
+	}
+
+	return names, nil
+}
+
+// Temporary reports whether err is worth retrying: transport errors, server errors and rate limiting.
+// Errors with a Status method, like *idbclient.ErrStatus, are HTTP status errors.
+func Temporary(err error) bool {
+	var es interface{ Status() int }
+	if !errors.As(err, &es) {
+		return true
+	}
+
+	return es.Status() >= 500 || es.Status() == http.StatusTooManyRequests
+}
+
+// backoff returns the delay after attempts failed replays.
+func (s *Spool) backoff(attempts int) time.Duration {
+	d := s.Backoff
+	for n := 1; n < attempts && d < s.MaxBackoff; n++ {
+		d *= 2
+	}
+	if s.MaxBackoff > 0 && d > s.MaxBackoff {
+		d = s.MaxBackoff
+	}
+	return d
+}
+
+// replay submits the entry of fqdn. The entry is removed if it was accepted or rejected permanently,
+// otherwise its backoff is increased.
+func (s *Spool) replay(u Updater, fqdn string, e *entry) error {
+	m, err := e.machine()
+	invalid := err != nil
+	if !invalid {
+		_, err = u.UpdateMachine(m, e.Create)
+	}
+
+	if err == nil || invalid || !Temporary(err) {
+		if rerr := os.Remove(s.path(fqdn)); rerr != nil && !os.IsNotExist(rerr) {
+			return rerr
+		}
+		if err != nil {
+			return &EntryError{fqdn, err}
+		}
+		return nil
+	}
+
+	e.Attempts++
+	e.NextAttempt = s.now().Add(s.backoff(e.Attempts))
+	e.LastError = err.Error()
+
+	if werr := s.write(fqdn, e); werr != nil {
+		return werr
+	}
+
+	return &ErrSpooled{fqdn, err}
+}
+
+// Update spools an update of m and replays it immediately. If the replay fails temporarily, the update
+// stays spooled and an *ErrSpooled is returned.
+func (s *Spool) Update(u Updater, m *machine.Machine, create bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.add(m, create)
+	if err != nil {
+		return err
+	}
+
+	e, err := s.read(s.path(m.Fqdn))
+	if err != nil {
+		return err
+	}
+
+	return s.replay(u, m.Fqdn, e)
+}
+
+// Result summarizes a Flush.
+type Result struct {
+	// Entries accepted by the IDB.
+	Sent int
+
+	// Entries dropped because they weren't updated for MaxAge.
+	Expired int
+
+	// Entries kept because their backoff hasn't passed or a previous entry failed.
+	Deferred int
+
+	// Entries rejected by the IDB and dropped.
+	Rejected []*EntryError
+
+	// Entries which couldn't be read or decoded, moved aside for inspection.
+	Unreadable []*EntryError
+}
+
+// moveAside renames the entry file of fqdn, so it is no longer replayed.
+func (s *Spool) moveAside(fqdn string) error {
+	path := s.path(fqdn)
+	return os.Rename(path, filepath.Join(s.dir, ".bad-"+filepath.Base(path)))
+}
+
+// Flush replays all due entries in order. It stops at the first temporary failure, deferring the remaining
+// entries, and returns that failure as *ErrSpooled. Unreadable entries are moved aside and don't stop the
+// others. Flush is meant to be called periodically, e.g. from cron.
+func (s *Spool) Flush(u Updater) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var r Result
+
+	names, err := s.names()
+	if err != nil {
+		return r, err
+	}
+
+	entries := make(map[string]*entry, len(names))
+	readable := names[:0]
+	for _, n := range names {
+		e, err := s.read(s.path(n))
+		if err != nil {
+			if merr := s.moveAside(n); merr != nil {
+				return r, merr
+			}
+			r.Unreadable = append(r.Unreadable, &EntryError{n, err})
+			continue
+		}
+		entries[n] = e
+		readable = append(readable, n)
+	}
+	names = readable
+
+	sort.Slice(names, func(i, j int) bool {
+		a, b := entries[names[i]], entries[names[j]]
+		if !a.Queued.Equal(b.Queued) {
+			return a.Queued.Before(b.Queued)
+		}
+		return names[i] < names[j]
+	})
+
+	now := s.now()
+	var failed error
+
+	for _, n := range names {
+		e := entries[n]
+
+		if s.MaxAge > 0 && now.Sub(e.age()) > s.MaxAge {
+			if err := os.Remove(s.path(n)); err != nil {
+				return r, err
+			}
+			r.Expired++
+			continue
+		}
+
+		if failed != nil || now.Before(e.NextAttempt) {
+			r.Deferred++
+			continue
+		}
+
+		err := s.replay(u, n, e)
+
+		var ee *EntryError
+		var es *ErrSpooled
+		switch {
+		case err == nil:
+			r.Sent++
+		case errors.As(err, &ee):
+			r.Rejected = append(r.Rejected, ee)
+		case errors.As(err, &es):
+			r.Deferred++
+			failed = err
+		default:
+			return r, err
+		}
+	}
+
+	return r, failed
+}
@@ -0,0 +1,205 @@
+package spool
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/idb-project/idbclient/machine"
+)
+
+type fakeUpdater struct {
+	err     error
+	updates []machine.Machine
+}
+
+func (f *fakeUpdater) UpdateMachine(m *machine.Machine, create bool) (*machine.Machine, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	m.CreateMachine = create
+	f.updates = append(f.updates, *m)
+	return m, nil
+}
+
+func TestSpool(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	down := &fakeUpdater{err: errors.New("connection refused")}
+
+	err = s.Update(down, &machine.Machine{Fqdn: "b.example.com", Cores: 2}, true)
+	var es *ErrSpooled
+	if !errors.As(err, &es) {
+		t.Log("expected spooled error, got", err)
+		t.Fail()
+	}
+
+	now = now.Add(time.Second)
+	s.Add(&machine.Machine{Fqdn: "a.example.com", Cores: 1}, false)
+	s.Add(&machine.Machine{Fqdn: "b.example.com", RAM: 1024}, false)
+
+	if n, _ := s.Len(); n != 2 {
+		t.Log("expected 2 entries, got", n)
+		t.Fail()
+	}
+
+	up := new(fakeUpdater)
+	r, err := s.Flush(up)
+	if err != nil || r.Sent != 2 {
+		t.Logf("flush failed: %+v %v", r, err)
+		t.FailNow()
+	}
+
+	// b.example.com was spooled first and contains both updates
+	b := up.updates[0]
+	if b.Fqdn != "b.example.com" || b.Cores != 2 || b.RAM != 1024 || !b.CreateMachine {
+		t.Logf("unexpected merged update %+v", b)
+		t.Fail()
+	}
+	if up.updates[1].Fqdn != "a.example.com" {
+		t.Log("unexpected replay order", up.updates[1].Fqdn)
+		t.Fail()
+	}
+
+	if n, _ := s.Len(); n != 0 {
+		t.Log("expected empty spool, got", n)
+		t.Fail()
+	}
+}
+
+func TestSpoolBackoff(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	s.MaxAge = time.Hour
+
+	down := &fakeUpdater{err: errors.New("connection refused")}
+	s.Add(&machine.Machine{Fqdn: "a.example.com", Cores: 1}, false)
+	s.Add(&machine.Machine{Fqdn: "b.example.com", Cores: 1}, false)
+
+	r, err := s.Flush(down)
+	if err == nil || r.Deferred != 2 {
+		t.Logf("expected failure deferring both entries: %+v %v", r, err)
+		t.Fail()
+	}
+
+	up := new(fakeUpdater)
+	now = now.Add(s.Backoff / 2)
+	r, _ = s.Flush(up)
+	if r.Sent != 1 || r.Deferred != 1 {
+		t.Logf("expected only the entry without backoff to be sent: %+v", r)
+		t.Fail()
+	}
+
+	now = now.Add(2 * time.Hour)
+	r, _ = s.Flush(up)
+	if r.Expired != 1 || r.Sent != 0 {
+		t.Logf("expected expired entry: %+v", r)
+		t.Fail()
+	}
+}
+
+func TestSpoolMaxAgeMerged(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	s.MaxAge = time.Hour
+
+	// the host keeps reporting while the IDB is down longer than MaxAge
+	down := &fakeUpdater{err: errors.New("connection refused")}
+	for n := 0; n < 4; n++ {
+		s.Update(down, &machine.Machine{Fqdn: "a.example.com", Cores: n + 1}, false)
+		now = now.Add(30 * time.Minute)
+	}
+
+	up := new(fakeUpdater)
+	r, err := s.Flush(up)
+	if err != nil || r.Sent != 1 || r.Expired != 0 {
+		t.Logf("expected the recently updated entry to be sent: %+v %v", r, err)
+		t.FailNow()
+	}
+	if up.updates[0].Cores != 4 {
+		t.Logf("expected newest merged data, got %+v", up.updates[0])
+		t.Fail()
+	}
+}
+
+func TestSpoolRejected(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Add(&machine.Machine{Fqdn: "a.example.com"}, false)
+
+	rejecting := &fakeUpdater{err: idbStatus(http.StatusNotFound)}
+	r, err := s.Flush(rejecting)
+	if err != nil || len(r.Rejected) != 1 {
+		t.Logf("expected rejected entry: %+v %v", r, err)
+		t.Fail()
+	}
+
+	files, _ := os.ReadDir(s.Dir())
+	if len(files) != 0 {
+		t.Log("rejected entry or temporary files left", len(files))
+		t.Fail()
+	}
+}
+
+type idbStatus int
+
+func (s idbStatus) Error() string {
+	return http.StatusText(int(s))
+}
+
+func (s idbStatus) Status() int {
+	return int(s)
+}
+
+func TestSpoolUnreadable(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Add(&machine.Machine{Fqdn: "a.example.com", Cores: 1}, false)
+	s.Add(&machine.Machine{Fqdn: "b.example.com", Cores: 2}, false)
+
+	err = os.WriteFile(s.path("a.example.com"), []byte(`{"queued":`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up := new(fakeUpdater)
+	r, err := s.Flush(up)
+	if err != nil || r.Sent != 1 || len(r.Unreadable) != 1 || r.Unreadable[0].Fqdn != "a.example.com" {
+		t.Logf("flush with unreadable entry: %+v %v", r, err)
+		t.Fail()
+	}
+
+	if n, _ := s.Len(); n != 0 {
+		t.Log("expected empty spool, got", n)
+		t.Fail()
+	}
+	if _, err := os.Stat(filepath.Join(s.Dir(), ".bad-a.example.com.json")); err != nil {
+		t.Log("unreadable entry not moved aside:", err)
+		t.Fail()
+	}
+}